@@ -0,0 +1,239 @@
+package apiktest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+)
+
+// ErrNoInteraction is returned by Recorder in replay mode if the cassette has no matching interaction
+var ErrNoInteraction = errors.New("no recorded interaction matches the request")
+
+// Mode represents the mode of the Recorder
+type Mode int
+
+const (
+	// ModeReplay serves requests from the cassette, without network access
+	ModeReplay Mode = iota
+	// ModeRecord sends requests to the real server and records interactions into the cassette
+	ModeRecord
+	// ModeAuto replays the cassette if its file exists, otherwise records a new one
+	ModeAuto
+)
+
+// Cassette represents recorded HTTP interactions
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction represents a recorded request and its response
+type Interaction struct {
+	Request  CassetteRequest  `json:"request"`
+	Response CassetteResponse `json:"response"`
+
+	replayed bool
+}
+
+// CassetteRequest represents a recorded request. Request headers are not recorded, so secrets don't leak into cassettes.
+type CassetteRequest struct {
+	Method string   `json:"method"`
+	URL    string   `json:"url"`
+	Body   bodyData `json:"body,omitempty"`
+}
+
+// CassetteResponse represents a recorded response
+type CassetteResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       bodyData    `json:"body,omitempty"`
+}
+
+// bodyData is a body, that is stored as a string if it is a valid UTF-8, otherwise as base64
+type bodyData []byte
+
+func (b bodyData) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		return json.Marshal(string(b))
+	}
+	return json.Marshal(map[string]string{"base64": base64.StdEncoding.EncodeToString(b)})
+}
+
+func (b *bodyData) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*b = []byte(s)
+		return nil
+	}
+	var encoded map[string]string
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded["base64"])
+	*b = decoded
+	return err
+}
+
+// Recorder is an http.RoundTripper that records HTTP interactions into a cassette file and replays them.
+// Requests are matched by method, URL and body. In replay mode, every interaction is replayed once
+// in the order of recording, then the last matching one is repeated.
+// It is safe for concurrent use.
+type Recorder struct {
+	path     string
+	mode     Mode
+	rt       http.RoundTripper
+	mu       sync.Mutex
+	cassette *Cassette
+}
+
+// NewRecorder creates a Recorder for the cassette file at path.
+// In record mode requests are sent with rt, or http.DefaultTransport if rt is nil.
+// In replay mode the cassette file must exist.
+func NewRecorder(path string, mode Mode, rt http.RoundTripper) (*Recorder, error) {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if mode == ModeAuto {
+		mode = ModeReplay
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			mode = ModeRecord
+		}
+	}
+
+	r := &Recorder{path: path, mode: mode, rt: rt, cassette: &Cassette{}}
+	if mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(data, r.cassette); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Mode returns the actual mode of the Recorder
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Client returns an http.Client with the Recorder, that can be passed to apik.WithHttpClient
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Save writes the recorded interactions into the cassette file. It does nothing in replay mode.
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, data, 0o644)
+}
+
+// RoundTrip records or replays the request, depending on the mode
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	recorded := CassetteRequest{Method: req.Method, URL: req.URL.String(), Body: body}
+
+	if r.mode == ModeReplay {
+		return r.replay(req, recorded)
+	}
+	return r.record(req, recorded)
+}
+
+func (r *Recorder) replay(req *http.Request, recorded CassetteRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matched *Interaction
+	for _, interaction := range r.cassette.Interactions {
+		if !interaction.matches(recorded) {
+			continue
+		}
+		matched = interaction
+		if !interaction.replayed {
+			break
+		}
+	}
+	if matched == nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, req.Method, req.URL)
+	}
+	matched.replayed = true
+	return matched.Response.response(req), nil
+}
+
+func (r *Recorder) record(req *http.Request, recorded CassetteRequest) (*http.Response, error) {
+	outReq := req.Clone(req.Context())
+	outReq.Body = io.NopCloser(bytes.NewReader(recorded.Body))
+	if req.Body == nil {
+		outReq.Body = nil
+	}
+	resp, err := r.rt.RoundTrip(outReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	interaction := &Interaction{
+		Request: recorded,
+		Response: CassetteResponse{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header.Clone(),
+			Body:       body,
+		},
+	}
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+
+	return interaction.Response.response(req), nil
+}
+
+func (i *Interaction) matches(req CassetteRequest) bool {
+	return i.Request.Method == req.Method && i.Request.URL == req.URL && bytes.Equal(i.Request.Body, req.Body)
+}
+
+func (r CassetteResponse) response(req *http.Request) *http.Response {
+	header := r.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	header.Set("Content-Length", strconv.Itoa(len(r.Body)))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
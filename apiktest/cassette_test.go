@@ -0,0 +1,77 @@
+package apiktest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/niklak/apik"
+	"github.com/niklak/apik/reqopt"
+	"github.com/niklak/apik/request"
+)
+
+func TestRecorder(t *testing.T) {
+	var hits int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("Content-Type", "application/octet-stream")
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+		}
+		w.Write([]byte{0xff, 0xfe, byte(hits)})
+	}))
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	fetch := func(client *apik.Client, opts ...request.RequestOption) (*apik.Response, []byte, error) {
+		var body []byte
+		resp, err := client.Fetch(request.NewRequest(context.Background(), ts.URL+"/items", opts...), &body)
+		return resp, body, err
+	}
+
+	rec, err := NewRecorder(path, ModeAuto, nil)
+	require.NoError(t, err)
+	assert.Equal(t, ModeRecord, rec.Mode())
+	client := apik.New(apik.WithHttpClient(rec.Client()))
+
+	_, body, err := fetch(client)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xff, 0xfe, 1}, body)
+	_, _, err = fetch(client)
+	require.NoError(t, err)
+	resp, _, err := fetch(client, reqopt.Method(http.MethodPost), reqopt.SetText("new"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.NoError(t, rec.Save())
+
+	ts.Close()
+
+	rec, err = NewRecorder(path, ModeAuto, nil)
+	require.NoError(t, err)
+	assert.Equal(t, ModeReplay, rec.Mode())
+	client = apik.New(apik.WithHttpClient(rec.Client()))
+
+	for _, expected := range []byte{1, 2, 2} {
+		_, body, err = fetch(client)
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0xff, 0xfe, expected}, body)
+	}
+
+	resp, body, err = fetch(client, reqopt.Method(http.MethodPost), reqopt.SetText("new"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "application/octet-stream", resp.Raw.Header.Get("Content-Type"))
+	assert.Equal(t, []byte{0xff, 0xfe, 3}, body)
+
+	_, _, err = fetch(client, reqopt.Method(http.MethodPost), reqopt.SetText("other"))
+	assert.True(t, errors.Is(err, ErrNoInteraction))
+
+	_, err = NewRecorder(filepath.Join(t.TempDir(), "missing.json"), ModeReplay, nil)
+	assert.Error(t, err)
+}
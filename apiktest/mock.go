@@ -0,0 +1,181 @@
+// Package apiktest provides helpers for testing code that uses apik without running a server.
+package apiktest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// ErrNoRoute is returned by MockTransport if no route matches the request
+var ErrNoRoute = errors.New("no route matches the request")
+
+// RecordedRequest represents a request received by MockTransport
+type RecordedRequest struct {
+	Method string
+	URL    *url.URL
+	Header http.Header
+	Body   []byte
+}
+
+// Route represents a registered route of MockTransport and its canned response
+type Route struct {
+	method string
+	path   *regexp.Regexp
+	body   *regexp.Regexp
+
+	status       int
+	header       http.Header
+	responseBody []byte
+	err          error
+}
+
+// MatchBody restricts the route to requests with a body matching the regular expression
+func (r *Route) MatchBody(pattern string) *Route {
+	r.body = regexp.MustCompile(pattern)
+	return r
+}
+
+// Respond sets the status code and the body of the response
+func (r *Route) Respond(status int, body string) *Route {
+	r.status = status
+	r.responseBody = []byte(body)
+	return r
+}
+
+// RespondJSON sets the status code and the body of the response, encoded as JSON.
+// It panics if v can't be encoded.
+func (r *Route) RespondJSON(status int, v any) *Route {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	r.status = status
+	r.responseBody = body
+	r.header.Set("Content-Type", "application/json")
+	return r
+}
+
+// WithHeader adds a header to the response
+func (r *Route) WithHeader(key, value string) *Route {
+	r.header.Add(key, value)
+	return r
+}
+
+// RespondError makes the route fail with the error, as if the request could not be sent
+func (r *Route) RespondError(err error) *Route {
+	r.err = err
+	return r
+}
+
+func (r *Route) match(req *http.Request, body []byte) bool {
+	if r.method != "" && r.method != req.Method {
+		return false
+	}
+	if !r.path.MatchString(req.URL.Path) {
+		return false
+	}
+	return r.body == nil || r.body.Match(body)
+}
+
+func (r *Route) response(req *http.Request) *http.Response {
+	header := r.header.Clone()
+	header.Set("Content-Length", strconv.Itoa(len(r.responseBody)))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.status, http.StatusText(r.status)),
+		StatusCode:    r.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(r.responseBody)),
+		ContentLength: int64(len(r.responseBody)),
+		Request:       req,
+	}
+}
+
+// MockTransport is an http.RoundTripper that responds to requests with canned responses of registered routes.
+// Routes are matched in the order of registration. It records all received requests.
+// It is safe for concurrent use.
+type MockTransport struct {
+	mu       sync.Mutex
+	routes   []*Route
+	requests []*RecordedRequest
+}
+
+// NewMockTransport creates a new MockTransport without routes
+func NewMockTransport() *MockTransport {
+	return &MockTransport{}
+}
+
+// On registers a route for the method and the URL path matching the regular expression.
+// An empty method matches any method. By default, the route responds with 200 and an empty body.
+func (m *MockTransport) On(method, pathPattern string) *Route {
+	route := &Route{
+		method: method,
+		path:   regexp.MustCompile(pathPattern),
+		status: http.StatusOK,
+		header: make(http.Header),
+	}
+	m.mu.Lock()
+	m.routes = append(m.routes, route)
+	m.mu.Unlock()
+	return route
+}
+
+// Client returns an http.Client with the MockTransport, that can be passed to apik.WithHttpClient
+func (m *MockTransport) Client() *http.Client {
+	return &http.Client{Transport: m}
+}
+
+// Requests returns the requests received so far
+func (m *MockTransport) Requests() []*RecordedRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*RecordedRequest(nil), m.requests...)
+}
+
+// RoundTrip records the request and responds with the first matching route.
+// If no route matches, ErrNoRoute is returned.
+func (m *MockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	m.mu.Lock()
+	m.requests = append(m.requests, &RecordedRequest{
+		Method: req.Method,
+		URL:    req.URL,
+		Header: req.Header.Clone(),
+		Body:   body,
+	})
+	var matched *Route
+	for _, route := range m.routes {
+		if route.match(req, body) {
+			matched = route
+			break
+		}
+	}
+	m.mu.Unlock()
+
+	if matched == nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoRoute, req.Method, req.URL)
+	}
+	if matched.err != nil {
+		return nil, matched.err
+	}
+	return matched.response(req), nil
+}
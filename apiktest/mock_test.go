@@ -0,0 +1,66 @@
+package apiktest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/niklak/apik"
+	"github.com/niklak/apik/reqopt"
+	"github.com/niklak/apik/request"
+)
+
+func TestMockTransport(t *testing.T) {
+
+	mock := NewMockTransport()
+	mock.On(http.MethodGet, `^/users/\d+$`).RespondJSON(http.StatusOK, map[string]string{"name": "admin"})
+	mock.On(http.MethodPost, `^/users$`).MatchBody(`"name":"taken"`).Respond(http.StatusConflict, "conflict")
+	mock.On(http.MethodPost, `^/users$`).Respond(http.StatusCreated, "").WithHeader("Location", "/users/2")
+	errDown := errors.New("down")
+	mock.On("", `^/down$`).RespondError(errDown)
+
+	client := apik.New(apik.WithBaseUrl("http://api.test"), apik.WithHttpClient(mock.Client()))
+
+	type user struct {
+		Name string `json:"name"`
+	}
+
+	result := new(user)
+	resp, err := client.JSON(request.NewRequest(context.Background(), "/users/1"), result)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "admin", result.Name)
+
+	var body string
+	resp, err = client.Fetch(
+		request.NewRequest(context.Background(), "/users", reqopt.Method(http.MethodPost), reqopt.SetJSON(user{Name: "taken"})),
+		&body,
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "conflict", body)
+
+	resp, err = client.Fetch(
+		request.NewRequest(context.Background(), "/users", reqopt.Method(http.MethodPost), reqopt.SetJSON(user{Name: "new"})),
+		nil,
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "/users/2", resp.Raw.Header.Get("Location"))
+
+	_, err = client.Fetch(request.NewRequest(context.Background(), "/down"), nil)
+	assert.ErrorIs(t, err, errDown)
+
+	_, err = client.Fetch(request.NewRequest(context.Background(), "/unknown"), nil)
+	assert.ErrorIs(t, err, ErrNoRoute)
+
+	requests := mock.Requests()
+	assert.Len(t, requests, 5)
+	assert.Equal(t, http.MethodPost, requests[2].Method)
+	assert.Equal(t, "/users", requests[2].URL.Path)
+	assert.Equal(t, "application/json", requests[2].Header.Get("Content-Type"))
+	assert.JSONEq(t, `{"name":"new"}`, string(requests[2].Body))
+}
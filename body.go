@@ -0,0 +1,127 @@
+package apik
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+)
+
+// maxDrainBytes is the maximum number of bytes read from the unread body before closing it,
+// so the connection can be reused
+const maxDrainBytes = 64 << 10
+
+// contextReader is an io.Reader that aborts reading with the context error once the context is done.
+// If count is set, the number of read bytes is added to it.
+type contextReader struct {
+	ctx   context.Context
+	r     io.Reader
+	count *int64
+}
+
+func (r *contextReader) Read(p []byte) (n int, err error) {
+	if err = r.ctx.Err(); err != nil {
+		return
+	}
+	n, err = r.r.Read(p)
+	if r.count != nil {
+		*r.count += int64(n)
+	}
+	if err != nil && err != io.EOF {
+		if ctxErr := r.ctx.Err(); ctxErr != nil {
+			err = ctxErr
+		}
+	}
+	return
+}
+
+// drainAndClose reads the rest of the body (up to maxDrainBytes) and closes it
+func drainAndClose(body io.ReadCloser) {
+	io.CopyN(io.Discard, body, maxDrainBytes)
+	body.Close()
+}
+
+// bodyReader returns a reader of the response body, that aborts on the context cancellation
+// and counts read bytes in BytesReceived
+func (r *Response) bodyReader(ctx context.Context, body io.Reader) io.Reader {
+	return &contextReader{ctx: ctx, r: body, count: &r.BytesReceived}
+}
+
+// defaultBufferLimit is the default maximum size of the response body buffered with WithBufferResponseBody
+const defaultBufferLimit = 1 << 20
+
+// WithBufferResponseBody makes Fetch, JSON and JSONResult read the whole response body into memory before
+// it is decoded, so the exact bytes are available with Response.Body, e.g. for logging and auditing.
+// Bodies larger than the limit are not buffered: they are read as usual and Response.Body returns nil.
+// If the limit is not positive, it is 1MB.
+func WithBufferResponseBody(limit int64) ClientOption {
+	return func(c *Client) {
+		if limit <= 0 {
+			limit = defaultBufferLimit
+		}
+		c.bufferLimit = limit
+	}
+}
+
+// Body returns the response body, if it was buffered, see WithBufferResponseBody
+func (r *Response) Body() []byte {
+	return r.body
+}
+
+// Trailer returns the first value of the response trailer, e.g. `grpc-status` of gRPC-Web.
+// Trailers are available only after the body is read to the end, as Fetch, JSON, JSONResult, SSE and FetchLines do.
+func (r *Response) Trailer(key string) string {
+	return r.Raw.Trailer.Get(key)
+}
+
+// readRest reads the remainder of the body after a decoded JSON value (up to maxDrainBytes),
+// so the trailers of the response are populated.
+// It returns ErrTrailingData if the remainder is not whitespace.
+func readRest(body io.Reader) error {
+	buf := make([]byte, 512)
+	r := io.LimitReader(body, maxDrainBytes)
+	for {
+		n, err := r.Read(buf)
+		for _, b := range buf[:n] {
+			if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+				return ErrTrailingData
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// bufferBody reads the body into the response, if buffering is enabled and the body fits the limit.
+// It returns the reader of the whole body.
+func (c *Client) bufferBody(resp *Response, body io.Reader) (io.Reader, error) {
+	if c.bufferLimit <= 0 {
+		return body, nil
+	}
+	data, err := io.ReadAll(io.LimitReader(body, c.bufferLimit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > c.bufferLimit {
+		return io.MultiReader(bytes.NewReader(data), body), nil
+	}
+	resp.body = data
+	return bytes.NewReader(data), nil
+}
+
+// closeNotifier is an io.ReadCloser that calls onClose once, when it is closed
+type closeNotifier struct {
+	io.ReadCloser
+	once    sync.Once
+	onClose func()
+}
+
+func (c *closeNotifier) Close() error {
+	err := c.ReadCloser.Close()
+	c.once.Do(c.onClose)
+	return err
+}
@@ -0,0 +1,189 @@
+package apik
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/niklak/apik/reqopt"
+	"github.com/niklak/apik/request"
+)
+
+func TestClient_FetchCancelDuringRead(t *testing.T) {
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for {
+			if _, err := w.Write([]byte("chunk\n")); err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}))
+	defer testServer.Close()
+
+	client := New(WithBaseUrl(testServer.URL))
+	goroutines := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.Fetch(request.NewRequest(ctx, "/"), io.Discard)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+
+	client.c.CloseIdleConnections()
+	// wait for the connection goroutines to exit
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > goroutines && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), goroutines)
+}
+
+func TestClient_BufferResponseBody(t *testing.T) {
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name": "apik",  "extra": true}`))
+	}))
+	defer testServer.Close()
+
+	client := New(WithBaseUrl(testServer.URL), WithBufferResponseBody(0))
+
+	var result struct {
+		Name string `json:"name"`
+	}
+	resp, err := client.JSON(request.NewRequest(context.Background(), "/"), &result)
+	require.NoError(t, err)
+	assert.Equal(t, "apik", result.Name)
+	assert.Equal(t, `{"name": "apik",  "extra": true}`, string(resp.Body()))
+	assert.Equal(t, int64(32), resp.BytesReceived)
+
+	var body string
+	resp, err = client.Fetch(request.NewRequest(context.Background(), "/"), &body)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(resp.Body()))
+
+	// a body larger than the limit is decoded, but not buffered
+	client = New(WithBaseUrl(testServer.URL), WithBufferResponseBody(10))
+	resp, err = client.JSON(request.NewRequest(context.Background(), "/"), &result)
+	require.NoError(t, err)
+	assert.Equal(t, "apik", result.Name)
+	assert.Nil(t, resp.Body())
+
+	// without the option the body is not buffered
+	resp, err = New(WithBaseUrl(testServer.URL)).Fetch(request.NewRequest(context.Background(), "/"), &body)
+	require.NoError(t, err)
+	assert.Nil(t, resp.Body())
+}
+
+func TestClient_Trailer(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// a declared trailer and an undeclared one with the trailer prefix
+		w.Header().Set("Trailer", "Grpc-Status")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}` + "\n\n"))
+		w.(http.Flusher).Flush()
+		w.Header().Set("Grpc-Status", "0")
+		w.Header().Set(http.TrailerPrefix+"Grpc-Message", "done")
+	}))
+	defer testServer.Close()
+
+	client := New(WithBaseUrl(testServer.URL))
+
+	resp, err := client.Fetch(request.NewRequest(context.Background(), "/"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "0", resp.Trailer("grpc-status"))
+	assert.Equal(t, "done", resp.Trailer("Grpc-Message"))
+
+	var result struct {
+		OK bool `json:"ok"`
+	}
+	resp, err = client.JSON(request.NewRequest(context.Background(), "/"), &result)
+	require.NoError(t, err)
+	assert.True(t, result.OK)
+	assert.Equal(t, "0", resp.Trailer("Grpc-Status"))
+	assert.Equal(t, "done", resp.Trailer("Grpc-Message"))
+	assert.Empty(t, resp.Trailer("X-Missing"))
+}
+
+func TestClient_JSONTrailingData(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+		if r.URL.Path == "/garbage" {
+			w.Write([]byte(" {\"ok\":false}"))
+			return
+		}
+		// whitespace beyond the drain limit is not read to the end
+		w.Write([]byte(strings.Repeat(" ", 2*maxDrainBytes)))
+	}))
+	defer testServer.Close()
+
+	client := New(WithBaseUrl(testServer.URL))
+
+	var result struct {
+		OK bool `json:"ok"`
+	}
+	resp, err := client.JSON(request.NewRequest(context.Background(), "/garbage"), &result)
+	assert.ErrorIs(t, err, ErrTrailingData)
+	assert.Nil(t, resp.Result)
+
+	resp, err = client.JSON(request.NewRequest(context.Background(), "/"), &result)
+	require.NoError(t, err)
+	assert.True(t, result.OK)
+	assert.Equal(t, &result, resp.Result)
+}
+
+func TestClient_IdleTimeout(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 5; i++ {
+			w.Write([]byte("chunk\n"))
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(50 * time.Millisecond):
+			}
+		}
+		if r.URL.Path == "/stall" {
+			<-r.Context().Done()
+		}
+	}))
+	defer testServer.Close()
+
+	client := New(WithBaseUrl(testServer.URL))
+
+	// the stream takes longer than the idle timeout, but bytes keep arriving
+	var body string
+	_, err := client.Fetch(request.NewRequest(context.Background(), "/stream", reqopt.IdleTimeout(200*time.Millisecond)), &body)
+	require.NoError(t, err)
+	assert.Equal(t, 5, strings.Count(body, "chunk"))
+
+	start := time.Now()
+	var lines int
+	_, err = client.FetchLines(request.NewRequest(context.Background(), "/stall", reqopt.IdleTimeout(200*time.Millisecond)),
+		func(line []byte) error {
+			lines++
+			return nil
+		},
+	)
+	assert.ErrorIs(t, err, ErrIdleTimeout)
+	assert.True(t, IsErrorKind(err, KindTimeout))
+	assert.Equal(t, 5, lines)
+	assert.Less(t, time.Since(start), time.Second)
+}
@@ -0,0 +1,76 @@
+package apik
+
+import (
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+// headerField is a header of the preset, presets keep headers in the order the browser sends them
+type headerField struct {
+	key   string
+	value string
+}
+
+// browserPresets contains header sets of a top-level navigation of desktop browsers.
+// Accept-Encoding is omitted, so the transport still requests gzip and decompresses the response.
+var browserPresets = map[string][]headerField{
+	"chrome": {
+		{"Sec-Ch-Ua", `"Google Chrome";v="129", "Not=A?Brand";v="8", "Chromium";v="129"`},
+		{"Sec-Ch-Ua-Mobile", "?0"},
+		{"Sec-Ch-Ua-Platform", `"Windows"`},
+		{"Upgrade-Insecure-Requests", "1"},
+		{"User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Safari/537.36"},
+		{"Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7"},
+		{"Sec-Fetch-Site", "none"},
+		{"Sec-Fetch-Mode", "navigate"},
+		{"Sec-Fetch-User", "?1"},
+		{"Sec-Fetch-Dest", "document"},
+		{"Accept-Language", "en-US,en;q=0.9"},
+	},
+	"firefox": {
+		{"User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:131.0) Gecko/20100101 Firefox/131.0"},
+		{"Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/png,image/svg+xml,*/*;q=0.8"},
+		{"Accept-Language", "en-US,en;q=0.5"},
+		{"Upgrade-Insecure-Requests", "1"},
+		{"Sec-Fetch-Dest", "document"},
+		{"Sec-Fetch-Mode", "navigate"},
+		{"Sec-Fetch-Site", "none"},
+		{"Sec-Fetch-User", "?1"},
+		{"Priority", "u=0, i"},
+	},
+}
+
+// WithBrowserHeaders sets the headers that a desktop browser sends when it navigates to a page.
+// Supported presets are "chrome" and "firefox", an unknown preset is ignored with a warning.
+// Headers set by the preset override the same client headers, request headers override them as usual.
+// net/http sends headers in sorted order, use WithHeaderOrder with BrowserHeaderOrder to send them in the browser order.
+func WithBrowserHeaders(preset string) ClientOption {
+	return func(c *Client) {
+		fields, ok := browserPresets[preset]
+		if !ok {
+			log.Warn().Str("module", "apik").Msgf("unknown browser headers preset %q", preset)
+			return
+		}
+		if c.header == nil {
+			c.header = make(http.Header)
+		}
+		for _, field := range fields {
+			c.header.Set(field.key, field.value)
+		}
+	}
+}
+
+// BrowserHeaderOrder returns the order of headers of the preset for WithHeaderOrder, starting with `Host`.
+// It returns nil for an unknown preset.
+func BrowserHeaderOrder(preset string) []string {
+	fields, ok := browserPresets[preset]
+	if !ok {
+		return nil
+	}
+	order := []string{"Host"}
+	for _, field := range fields {
+		order = append(order, field.key)
+	}
+	return order
+}
@@ -0,0 +1,42 @@
+package apik
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/niklak/apik/reqopt"
+	"github.com/niklak/apik/request"
+	"github.com/niklak/httpbulb"
+)
+
+func TestClient_BrowserHeaders(t *testing.T) {
+	testServer := httptest.NewServer(httpbulb.NewRouter())
+	defer testServer.Close()
+
+	type httpBinResponse struct {
+		Headers map[string][]string `json:"headers"`
+	}
+
+	for preset, fields := range browserPresets {
+		client := New(WithBaseUrl(testServer.URL), WithBrowserHeaders(preset))
+
+		result := new(httpBinResponse)
+		_, err := client.JSON(request.NewRequest(context.Background(), "/headers", reqopt.Header("Accept-Language", "de")), result)
+		assert.NoError(t, err)
+
+		for _, field := range fields {
+			if field.key == "Accept-Language" {
+				continue
+			}
+			assert.Equal(t, []string{field.value}, result.Headers[field.key], preset)
+		}
+		assert.Equal(t, []string{"de"}, result.Headers["Accept-Language"], preset)
+		assert.Equal(t, []string{"gzip"}, result.Headers["Accept-Encoding"], preset)
+	}
+
+	client := New(WithBrowserHeaders("netscape"))
+	assert.Empty(t, client.header)
+}
@@ -0,0 +1,128 @@
+package apik
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CircuitBreakerSettings represents settings of the circuit breaker
+type CircuitBreakerSettings struct {
+	// MaxFailures is the number of consecutive failures to a host that opens the circuit. Default is 5
+	MaxFailures int
+	// Cooldown is the time the circuit stays open before one probe request is allowed. Default is 30 seconds
+	Cooldown time.Duration
+	// IsFailure reports whether the result of the request is a failure.
+	// Default treats any error and 5xx status codes as failures.
+	// Requests canceled by the caller are neither failures nor successes, IsFailure is not called for them.
+	IsFailure func(resp *http.Response, err error) bool
+}
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// hostCircuit is the circuit state of a single host
+type hostCircuit struct {
+	state    circuitState
+	failures int
+	openedAt time.Time
+}
+
+// circuitBreaker tracks failures per host and short-circuits requests to failing hosts
+type circuitBreaker struct {
+	settings CircuitBreakerSettings
+	mu       sync.Mutex
+	hosts    map[string]*hostCircuit
+}
+
+func newCircuitBreaker(settings CircuitBreakerSettings) *circuitBreaker {
+	if settings.MaxFailures <= 0 {
+		settings.MaxFailures = 5
+	}
+	if settings.Cooldown <= 0 {
+		settings.Cooldown = 30 * time.Second
+	}
+	if settings.IsFailure == nil {
+		settings.IsFailure = defaultIsFailure
+	}
+	return &circuitBreaker{settings: settings, hosts: make(map[string]*hostCircuit)}
+}
+
+func defaultIsFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
+
+// allow returns ErrCircuitOpen if requests to the host are not allowed.
+// After the cooldown only one probe request is allowed, until its result is recorded.
+func (b *circuitBreaker) allow(host string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	hc, ok := b.hosts[host]
+	if !ok {
+		return nil
+	}
+
+	switch hc.state {
+	case circuitOpen:
+		if time.Since(hc.openedAt) < b.settings.Cooldown {
+			return ErrCircuitOpen
+		}
+		hc.state = circuitHalfOpen
+	case circuitHalfOpen:
+		// the probe request is still in flight
+		return ErrCircuitOpen
+	}
+	return nil
+}
+
+// record records the result of the request to the host
+func (b *circuitBreaker) record(host string, resp *http.Response, err error) {
+	if IsErrorKind(err, KindCanceled) {
+		b.release(host)
+		return
+	}
+	failed := b.settings.IsFailure(resp, err)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	hc, ok := b.hosts[host]
+	if !ok {
+		if !failed {
+			return
+		}
+		hc = &hostCircuit{}
+		b.hosts[host] = hc
+	}
+
+	if !failed {
+		delete(b.hosts, host)
+		return
+	}
+
+	hc.failures++
+	if hc.state == circuitHalfOpen || hc.failures >= b.settings.MaxFailures {
+		hc.state = circuitOpen
+		hc.openedAt = time.Now()
+	}
+}
+
+// release handles a request canceled by the caller, that says nothing about the host.
+// If it was the probe request, the circuit is open again, but the next request becomes the probe.
+func (b *circuitBreaker) release(host string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if hc, ok := b.hosts[host]; ok && hc.state == circuitHalfOpen {
+		hc.state = circuitOpen
+	}
+}
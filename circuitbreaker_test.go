@@ -0,0 +1,105 @@
+package apik
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/niklak/apik/request"
+)
+
+func TestClient_CircuitBreaker(t *testing.T) {
+
+	var failing atomic.Bool
+	failing.Store(true)
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer testServer.Close()
+
+	client := New(
+		WithBaseUrl(testServer.URL),
+		WithCircuitBreaker(CircuitBreakerSettings{MaxFailures: 2, Cooldown: 50 * time.Millisecond}),
+	)
+
+	fetch := func() (*Response, error) {
+		return client.Fetch(request.NewRequest(context.Background(), "/"), nil)
+	}
+
+	for i := 0; i < 2; i++ {
+		resp, err := fetch()
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	}
+
+	_, err := fetch()
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	// the probe request fails and opens the circuit again
+	time.Sleep(60 * time.Millisecond)
+	resp, err := fetch()
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	_, err = fetch()
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	// the probe request succeeds and closes the circuit
+	failing.Store(false)
+	time.Sleep(60 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		resp, err = fetch()
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestClient_CircuitBreakerCanceledProbe(t *testing.T) {
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-r.Context().Done()
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer testServer.Close()
+
+	client := New(
+		WithBaseUrl(testServer.URL),
+		WithCircuitBreaker(CircuitBreakerSettings{MaxFailures: 2, Cooldown: 50 * time.Millisecond}),
+	)
+
+	fetch := func(ctx context.Context, path string) (*Response, error) {
+		return client.Fetch(request.NewRequest(ctx, path), nil)
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := fetch(context.Background(), "/")
+		assert.NoError(t, err)
+	}
+	_, err := fetch(context.Background(), "/")
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	// the probe request is canceled by the caller, it doesn't close the circuit
+	time.Sleep(60 * time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	_, err = fetch(ctx, "/slow")
+	assert.True(t, IsErrorKind(err, KindCanceled))
+
+	// the next request becomes the probe, it fails and opens the circuit again
+	resp, err := fetch(context.Background(), "/")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	_, err = fetch(context.Background(), "/")
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+}
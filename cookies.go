@@ -0,0 +1,64 @@
+package apik
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// filteredJar is a cookie jar that stores only the cookies accepted by the filter
+type filteredJar struct {
+	http.CookieJar
+	filter func(cookie *http.Cookie) bool
+}
+
+// SetCookies stores the accepted cookies in the underlying jar
+func (j *filteredJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	accepted := make([]*http.Cookie, 0, len(cookies))
+	for _, cookie := range cookies {
+		if j.filter(cookie) {
+			accepted = append(accepted, cookie)
+		}
+	}
+	if len(accepted) > 0 {
+		j.CookieJar.SetCookies(u, accepted)
+	}
+}
+
+// CookiesForURL returns the cookies from the client's jar, that would be sent to the URL.
+// With the default jar, only the name and the value of the cookie are available.
+func (c *Client) CookiesForURL(u *url.URL) []*http.Cookie {
+	if c.c.Jar == nil {
+		return nil
+	}
+	return c.c.Jar.Cookies(u)
+}
+
+// SetCookies stores the cookies for the URL in the client's jar, e.g. to seed a session.
+// Like the cookies of WithCookies, they are not filtered by WithCookieFilter.
+// It is safe to call concurrently with requests, if the jar is safe for concurrent use, as the default one is.
+func (c *Client) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	if j := c.cookieJar(); j != nil {
+		j.SetCookies(u, cookies)
+	}
+}
+
+// ClearCookies removes all cookies from the client's jar, e.g. to reuse the client for another user.
+// The default jar and jar.Jar can be cleared, other jars must have a `Clear()` method, otherwise nothing is removed.
+// It is safe to call concurrently with requests, requests in flight may still send or store the old cookies.
+func (c *Client) ClearCookies() {
+	switch j := c.cookieJar().(type) {
+	case nil:
+	case interface{ Clear() }:
+		j.Clear()
+	default:
+		c.logger.Warn().Msgf("unable to clear cookies of the jar of type %T", j)
+	}
+}
+
+// cookieJar returns the jar of the http.Client without the cookie filter
+func (c *Client) cookieJar() http.CookieJar {
+	if f, ok := c.c.Jar.(*filteredJar); ok {
+		return f.CookieJar
+	}
+	return c.c.Jar
+}
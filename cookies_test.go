@@ -0,0 +1,72 @@
+package apik
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/niklak/apik/request"
+)
+
+func TestClient_CookieFilter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "secret", Path: "/"})
+		http.SetCookie(w, &http.Cookie{Name: "_ga", Value: "tracking", Path: "/"})
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	client := New(WithBaseUrl(server.URL))
+	_, err = client.Fetch(request.NewRequest(context.Background(), "/login"), nil)
+	require.NoError(t, err)
+	assert.Len(t, client.CookiesForURL(u), 2)
+
+	client = New(
+		WithBaseUrl(server.URL),
+		WithCookies([]*http.Cookie{{Name: "lang", Value: "en"}}),
+		WithCookieFilter(func(cookie *http.Cookie) bool { return cookie.Name == "session" }),
+	)
+	_, err = client.Fetch(request.NewRequest(context.Background(), "/login"), nil)
+	require.NoError(t, err)
+
+	cookies := client.CookiesForURL(u)
+	names := make([]string, 0, len(cookies))
+	for _, cookie := range cookies {
+		names = append(names, cookie.Name)
+	}
+	assert.ElementsMatch(t, []string{"lang", "session"}, names)
+}
+
+func TestClient_ClearCookies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "secret", Path: "/"})
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	client := New(
+		WithBaseUrl(server.URL),
+		WithCookieFilter(func(cookie *http.Cookie) bool { return cookie.Name == "session" }),
+	)
+	_, err = client.Fetch(request.NewRequest(context.Background(), "/login"), nil)
+	require.NoError(t, err)
+	assert.Len(t, client.CookiesForURL(u), 1)
+
+	client.ClearCookies()
+	assert.Empty(t, client.CookiesForURL(u))
+
+	// seeded cookies bypass the filter
+	client.SetCookies(u, []*http.Cookie{{Name: "lang", Value: "en"}})
+	cookies := client.CookiesForURL(u)
+	require.Len(t, cookies, 1)
+	assert.Equal(t, "lang", cookies[0].Name)
+}
@@ -0,0 +1,113 @@
+package apik
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSInfo is the CORS policy of a response, parsed from its `Access-Control-*` headers
+type CORSInfo struct {
+	// AllowOrigin is the value of Access-Control-Allow-Origin: "*", an origin or "null"
+	AllowOrigin string
+	// AllowMethods are the methods of Access-Control-Allow-Methods
+	AllowMethods []string
+	// AllowHeaders are the headers of Access-Control-Allow-Headers, in canonical form except "*"
+	AllowHeaders []string
+	// ExposeHeaders are the headers of Access-Control-Expose-Headers, in canonical form except "*"
+	ExposeHeaders []string
+	// AllowCredentials reports whether Access-Control-Allow-Credentials is "true"
+	AllowCredentials bool
+	// MaxAge is the time the preflight result may be cached for, -1 if Access-Control-Max-Age is missing or invalid
+	MaxAge time.Duration
+	// AllowPrivateNetwork reports whether Access-Control-Allow-Private-Network is "true"
+	AllowPrivateNetwork bool
+}
+
+// AllowsOrigin reports whether the origin is allowed, a wildcard doesn't allow requests with credentials
+func (i CORSInfo) AllowsOrigin(origin string, credentials bool) bool {
+	if i.AllowOrigin == "*" {
+		return !credentials
+	}
+	return i.AllowOrigin == origin && (!credentials || i.AllowCredentials)
+}
+
+// AllowsMethod reports whether the method is allowed. Like browsers, simple methods are always allowed
+// and a wildcard doesn't allow requests with credentials.
+func (i CORSInfo) AllowsMethod(method string, credentials bool) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost:
+		return true
+	}
+	for _, m := range i.AllowMethods {
+		if m == method || (m == "*" && !credentials) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsHeader reports whether the request header is allowed, a wildcard doesn't allow requests with credentials
+func (i CORSInfo) AllowsHeader(header string, credentials bool) bool {
+	header = http.CanonicalHeaderKey(header)
+	for _, h := range i.AllowHeaders {
+		if h == header || (h == "*" && !credentials) {
+			return true
+		}
+	}
+	return false
+}
+
+// CORS returns the CORS policy from the response headers
+func (r *Response) CORS() CORSInfo {
+	header := r.Raw.Header
+	info := CORSInfo{
+		AllowOrigin:         strings.TrimSpace(header.Get("Access-Control-Allow-Origin")),
+		AllowMethods:        headerList(header, "Access-Control-Allow-Methods", false),
+		AllowHeaders:        headerList(header, "Access-Control-Allow-Headers", true),
+		ExposeHeaders:       headerList(header, "Access-Control-Expose-Headers", true),
+		AllowCredentials:    strings.TrimSpace(header.Get("Access-Control-Allow-Credentials")) == "true",
+		MaxAge:              -1,
+		AllowPrivateNetwork: strings.TrimSpace(header.Get("Access-Control-Allow-Private-Network")) == "true",
+	}
+	if seconds, err := strconv.Atoi(strings.TrimSpace(header.Get("Access-Control-Max-Age"))); err == nil {
+		info.MaxAge = time.Duration(seconds) * time.Second
+	}
+	return info
+}
+
+// headerList returns the comma-separated values of all header lines with the name
+func headerList(header http.Header, name string, canonical bool) []string {
+	var list []string
+	for _, line := range header.Values(name) {
+		for _, v := range strings.Split(line, ",") {
+			v = strings.TrimSpace(v)
+			if v == "" {
+				continue
+			}
+			if canonical && v != "*" {
+				v = http.CanonicalHeaderKey(v)
+			}
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// Options sends an http.Request built from Request with the OPTIONS method and returns a Response,
+// the response body is discarded. To send a CORS preflight request, set the `Origin`,
+// `Access-Control-Request-Method` and `Access-Control-Request-Headers` headers, then inspect Response.CORS.
+func (c *Client) Options(req *Request) (resp *Response, err error) {
+	req = req.Clone()
+	req.Method = http.MethodOptions
+
+	var rawResp *http.Response
+	var attempts int
+	if rawResp, attempts, err = c.do(req); err != nil {
+		return
+	}
+	defer drainAndClose(rawResp.Body)
+	resp = c.newResponse(rawResp, attempts)
+	return
+}
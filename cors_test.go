@@ -0,0 +1,70 @@
+package apik
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/niklak/apik/reqopt"
+	"github.com/niklak/apik/request"
+)
+
+func TestClient_Options(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodOptions, r.Method)
+		assert.Equal(t, "PUT", r.Header.Get("Access-Control-Request-Method"))
+		w.Header().Set("Access-Control-Allow-Origin", r.Header.Get("Origin"))
+		w.Header().Set("Access-Control-Allow-Methods", "GET, PUT,DELETE")
+		w.Header().Add("Access-Control-Allow-Headers", "content-type, x-api-key")
+		w.Header().Add("Access-Control-Allow-Headers", "authorization")
+		w.Header().Set("Access-Control-Expose-Headers", "x-request-id")
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+		w.Header().Set("Access-Control-Max-Age", "600")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := New(WithBaseUrl(server.URL))
+	resp, err := client.Options(request.NewRequest(context.Background(), "/items",
+		reqopt.Method(http.MethodPut),
+		reqopt.Header("Origin", "https://app.example.com"),
+		reqopt.Header("Access-Control-Request-Method", "PUT"),
+	))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	cors := resp.CORS()
+	assert.Equal(t, CORSInfo{
+		AllowOrigin:      "https://app.example.com",
+		AllowMethods:     []string{"GET", "PUT", "DELETE"},
+		AllowHeaders:     []string{"Content-Type", "X-Api-Key", "Authorization"},
+		ExposeHeaders:    []string{"X-Request-Id"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}, cors)
+	assert.True(t, cors.AllowsOrigin("https://app.example.com", true))
+	assert.False(t, cors.AllowsOrigin("https://evil.example.com", false))
+	assert.True(t, cors.AllowsMethod("DELETE", true))
+	assert.False(t, cors.AllowsMethod("PATCH", false))
+	assert.True(t, cors.AllowsHeader("x-api-key", true))
+	assert.False(t, cors.AllowsHeader("X-Other", false))
+}
+
+func TestResponse_CORSWildcard(t *testing.T) {
+	resp := &Response{Raw: &http.Response{Header: http.Header{
+		"Access-Control-Allow-Origin":  {"*"},
+		"Access-Control-Allow-Headers": {"*"},
+	}}}
+	cors := resp.CORS()
+	assert.Equal(t, time.Duration(-1), cors.MaxAge)
+	assert.True(t, cors.AllowsOrigin("https://app.example.com", false))
+	assert.False(t, cors.AllowsOrigin("https://app.example.com", true))
+	assert.True(t, cors.AllowsHeader("X-Api-Key", false))
+	assert.False(t, cors.AllowsHeader("X-Api-Key", true))
+	assert.Empty(t, cors.AllowMethods)
+}
@@ -0,0 +1,17 @@
+package apik
+
+// Decode sends the request with the client and decodes the JSON response body into a new value of type T,
+// see Client.JSON. On error, the zero value of T is returned. If c is nil, the DefaultClient is used.
+//
+//	user, resp, err := apik.Decode[User](client, req)
+func Decode[T any](c *Client, req *Request) (result T, resp *Response, err error) {
+	if c == nil {
+		c = DefaultClient()
+	}
+	var v T
+	if resp, err = c.JSON(req, &v); err != nil {
+		return
+	}
+	result = v
+	return
+}
@@ -0,0 +1,48 @@
+package apik
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/niklak/apik/reqopt"
+	"github.com/niklak/apik/request"
+	"github.com/niklak/httpbulb"
+)
+
+func TestDecode(t *testing.T) {
+	testServer := httptest.NewServer(httpbulb.NewRouter())
+	defer testServer.Close()
+
+	client := New(WithBaseUrl(testServer.URL))
+
+	type httpBinResponse struct {
+		URL  string              `json:"url"`
+		Args map[string][]string `json:"args"`
+	}
+
+	result, resp, err := Decode[httpBinResponse](client, request.NewRequest(context.Background(), "/get", reqopt.AddParam("k", "v")))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, map[string][]string{"k": {"v"}}, result.Args)
+
+	// a pointer type is allocated as well
+	ptr, _, err := Decode[*httpBinResponse](client, request.NewRequest(context.Background(), "/get"))
+	assert.NoError(t, err)
+	assert.Equal(t, testServer.URL+"/get", ptr.URL)
+
+	args, _, err := Decode[map[string]any](client, request.NewRequest(context.Background(), "/xml"))
+	assert.Error(t, err)
+	assert.Nil(t, args)
+
+	// the value is partially decoded, but the zero value is returned
+	type mismatchedResponse struct {
+		Args map[string][]string `json:"args"`
+		URL  int                 `json:"url"`
+	}
+	mismatched, _, err := Decode[mismatchedResponse](client, request.NewRequest(context.Background(), "/get", reqopt.AddParam("k", "v")))
+	assert.Error(t, err)
+	assert.Zero(t, mismatched)
+}
@@ -0,0 +1,32 @@
+package apik
+
+import "sync/atomic"
+
+// defaultClient is the Client used by package-level functions
+var defaultClient atomic.Pointer[Client]
+
+// DefaultClient returns the Client used by package-level functions, such as Fetch and JSON.
+// It is created with default options on the first use, unless it was set with SetDefaultClient.
+func DefaultClient() *Client {
+	if c := defaultClient.Load(); c != nil {
+		return c
+	}
+	defaultClient.CompareAndSwap(nil, New())
+	return defaultClient.Load()
+}
+
+// SetDefaultClient replaces the Client used by package-level functions.
+// If c is nil, a new Client with default options is created on the next use.
+func SetDefaultClient(c *Client) {
+	defaultClient.Store(c)
+}
+
+// Fetch sends the request with the DefaultClient, see Client.Fetch
+func Fetch(req *Request, result any) (*Response, error) {
+	return DefaultClient().Fetch(req, result)
+}
+
+// JSON sends the request with the DefaultClient, see Client.JSON
+func JSON(req *Request, result any) (*Response, error) {
+	return DefaultClient().JSON(req, result)
+}
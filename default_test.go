@@ -0,0 +1,59 @@
+package apik
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/niklak/apik/request"
+	"github.com/niklak/httpbulb"
+)
+
+func TestDefaultClient(t *testing.T) {
+
+	testServer := httptest.NewServer(httpbulb.NewRouter())
+	defer testServer.Close()
+	defer SetDefaultClient(nil)
+
+	SetDefaultClient(nil)
+
+	clients := make([]*Client, 10)
+	wg := &sync.WaitGroup{}
+	for i := range clients {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			clients[i] = DefaultClient()
+		}(i)
+	}
+	wg.Wait()
+	for _, c := range clients {
+		assert.Same(t, clients[0], c)
+	}
+
+	type getResponse struct {
+		Headers map[string][]string `json:"headers"`
+	}
+
+	result := new(getResponse)
+	_, err := JSON(request.NewRequest(context.Background(), testServer.URL+"/get"), result)
+	assert.NoError(t, err)
+	assert.Empty(t, result.Headers["X-Client"])
+
+	client := New(WithBaseUrl(testServer.URL), WithHeader("X-Client", "custom"))
+	SetDefaultClient(client)
+	assert.Same(t, client, DefaultClient())
+
+	result = new(getResponse)
+	_, err = JSON(request.NewRequest(context.Background(), "/get"), result)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"custom"}, result.Headers["X-Client"])
+
+	var body string
+	resp, err := Fetch(request.NewRequest(context.Background(), "/status/201"), &body)
+	assert.NoError(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+}
@@ -0,0 +1,182 @@
+package apik
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// digestAuth holds credentials for HTTP Digest authentication (RFC 7616)
+type digestAuth struct {
+	username string
+	password string
+}
+
+// digestChallenge represents parameters of the `WWW-Authenticate: Digest` challenge
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string
+}
+
+// parseDigestChallenge parses the `WWW-Authenticate` header value.
+// ok is false if the challenge is not a Digest challenge.
+func parseDigestChallenge(header string) (ch *digestChallenge, ok bool) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	if !strings.EqualFold(scheme, "digest") {
+		return
+	}
+
+	params := parseAuthParams(rest)
+	ch = &digestChallenge{
+		realm:     params["realm"],
+		nonce:     params["nonce"],
+		opaque:    params["opaque"],
+		algorithm: params["algorithm"],
+	}
+
+	// only qop=auth is supported
+	for _, qop := range strings.Split(params["qop"], ",") {
+		if strings.TrimSpace(qop) == "auth" {
+			ch.qop = "auth"
+			break
+		}
+	}
+
+	ok = ch.nonce != ""
+	return
+}
+
+// parseAuthParams parses comma-separated `key=value` or `key="value"` pairs
+func parseAuthParams(s string) map[string]string {
+	params := make(map[string]string)
+	for len(s) > 0 {
+		s = strings.TrimLeft(s, " ,")
+		key, rest, found := strings.Cut(s, "=")
+		if !found {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		rest = strings.TrimLeft(rest, " ")
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, s = rest[1:], ""
+			} else {
+				value, s = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			end := strings.IndexAny(rest, ", ")
+			if end < 0 {
+				value, s = rest, ""
+			} else {
+				value, s = rest[:end], rest[end:]
+			}
+		}
+		params[key] = value
+	}
+	return params
+}
+
+func (d *digestAuth) hashFunc(algorithm string) (func() hash.Hash, error) {
+	switch strings.ToUpper(algorithm) {
+	case "", "MD5":
+		return md5.New, nil
+	case "SHA-256":
+		return sha256.New, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDigestAlgorithm, algorithm)
+	}
+}
+
+// authorization computes the value of the `Authorization` header for the given request and challenge
+func (d *digestAuth) authorization(req *http.Request, ch *digestChallenge) (string, error) {
+	newHash, err := d.hashFunc(ch.algorithm)
+	if err != nil {
+		return "", err
+	}
+	h := func(s string) string {
+		hs := newHash()
+		io.WriteString(hs, s)
+		return hex.EncodeToString(hs.Sum(nil))
+	}
+
+	uri := req.URL.RequestURI()
+	ha1 := h(d.username + ":" + ch.realm + ":" + d.password)
+	ha2 := h(req.Method + ":" + uri)
+
+	parts := []string{
+		fmt.Sprintf(`username="%s"`, d.username),
+		fmt.Sprintf(`realm="%s"`, ch.realm),
+		fmt.Sprintf(`nonce="%s"`, ch.nonce),
+		fmt.Sprintf(`uri="%s"`, uri),
+	}
+
+	var response string
+	if ch.qop == "auth" {
+		b := make([]byte, 8)
+		if _, err = rand.Read(b); err != nil {
+			return "", err
+		}
+		cnonce := hex.EncodeToString(b)
+		nc := "00000001"
+		response = h(strings.Join([]string{ha1, ch.nonce, nc, cnonce, ch.qop, ha2}, ":"))
+		parts = append(parts, "qop=auth", "nc="+nc, fmt.Sprintf(`cnonce="%s"`, cnonce))
+	} else {
+		response = h(ha1 + ":" + ch.nonce + ":" + ha2)
+	}
+
+	parts = append(parts, fmt.Sprintf(`response="%s"`, response))
+	if ch.algorithm != "" {
+		parts = append(parts, "algorithm="+ch.algorithm)
+	}
+	if ch.opaque != "" {
+		parts = append(parts, fmt.Sprintf(`opaque="%s"`, ch.opaque))
+	}
+	return "Digest " + strings.Join(parts, ", "), nil
+}
+
+// retry answers the Digest challenge of resp, sending the request again with credentials.
+// If resp is not a Digest challenge, or the request body can't be sent again, it is returned as is.
+func (d *digestAuth) retry(hc *http.Client, req *http.Request, resp *http.Response) (*http.Response, error) {
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+	ch, ok := parseDigestChallenge(resp.Header.Get("WWW-Authenticate"))
+	if !ok {
+		return resp, nil
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		// the body is already sent and can't be sent again
+		return resp, nil
+	}
+
+	drainAndClose(resp.Body)
+
+	auth, err := d.authorization(req, ch)
+	if err != nil {
+		return nil, err
+	}
+
+	authReq := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if authReq.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	authReq.Header.Set("Authorization", auth)
+	if resp, err = hc.Do(authReq); err != nil {
+		return nil, newRequestError(err)
+	}
+	return resp, nil
+}
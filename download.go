@@ -0,0 +1,115 @@
+package apik
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// newHash returns a new hash.Hash for the algorithm name: md5, sha1, sha256 or sha512
+func newHash(algo string) (hash.Hash, error) {
+	switch strings.ToLower(algo) {
+	case "md5":
+		return md5.New(), nil
+	case "sha1":
+		return sha1.New(), nil
+	case "sha256":
+		return sha256.New(), nil
+	case "sha512":
+		return sha512.New(), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedHashAlgorithm, algo)
+}
+
+// DownloadVerified sends an http.Request built from Request and streams the response body into the file at path,
+// computing its checksum with the given algorithm (md5, sha1, sha256 or sha512) on the fly.
+// The expected checksum is a hex-encoded digest of the whole file.
+//
+// If the file already exists, the download is resumed with a `Range` header from the end of the file.
+// If the server ignores the range and responds with 200, the file is written from scratch.
+// An incomplete download is kept, so it can be resumed later.
+//
+// On checksum mismatch the file is deleted and ErrChecksumMismatch is returned.
+// Status codes other than 200 and 206 result in ErrUnexpectedStatus, the file is kept untouched.
+func (c *Client) DownloadVerified(req *Request, path, expected, algo string) (resp *Response, err error) {
+	var h hash.Hash
+	if h, err = newHash(algo); err != nil {
+		return
+	}
+
+	var f *os.File
+	if f, err = os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644); err != nil {
+		return
+	}
+	mismatch := false
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if mismatch {
+			os.Remove(path)
+		}
+	}()
+
+	// the partial content is hashed and the file position is left at its end
+	var offset int64
+	if offset, err = io.Copy(h, f); err != nil {
+		return
+	}
+	if offset > 0 && req.Header.Get("Range") == "" {
+		req = req.Clone()
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	var rawResp *http.Response
+	var attempts int
+	if rawResp, attempts, err = c.doStream(req); err != nil {
+		return
+	}
+	defer drainAndClose(rawResp.Body)
+	resp = c.newResponse(rawResp, attempts)
+
+	var src io.Reader = rawResp.Body
+	switch {
+	case rawResp.StatusCode == http.StatusPartialContent && offset > 0:
+		// the rest of the file is appended, if it starts where the file ends
+		if cr, ok := resp.ContentRange(); !ok || cr.Start != offset {
+			err = fmt.Errorf("%w: %d with Content-Range %q", ErrUnexpectedStatus,
+				rawResp.StatusCode, rawResp.Header.Get("Content-Range"))
+			return
+		}
+	case rawResp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// the file is already complete, only the checksum is verified
+		src = http.NoBody
+	case rawResp.StatusCode == http.StatusOK:
+		if err = f.Truncate(0); err != nil {
+			return
+		}
+		if _, err = f.Seek(0, io.SeekStart); err != nil {
+			return
+		}
+		h.Reset()
+	default:
+		err = fmt.Errorf("%w: %d", ErrUnexpectedStatus, rawResp.StatusCode)
+		return
+	}
+
+	body := resp.bodyReader(req.Ctx, src)
+	if _, err = io.Copy(io.MultiWriter(f, h), body); err != nil {
+		return
+	}
+
+	if sum := hex.EncodeToString(h.Sum(nil)); !strings.EqualFold(sum, expected) {
+		mismatch = true
+		err = fmt.Errorf("%w: expected %s, got %s", ErrChecksumMismatch, expected, sum)
+	}
+	return
+}
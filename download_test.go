@@ -0,0 +1,86 @@
+package apik
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/niklak/apik/request"
+)
+
+func TestClient_DownloadVerified(t *testing.T) {
+
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	sum := sha256.Sum256(content)
+	checksum := hex.EncodeToString(sum[:])
+
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		http.ServeContent(w, r, "file.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	client := New(WithBaseUrl(server.URL))
+	path := filepath.Join(t.TempDir(), "file.bin")
+
+	newRequest := func() *request.Request {
+		return request.NewRequest(context.Background(), "/file.bin")
+	}
+
+	// a fresh download
+	resp, err := client.DownloadVerified(newRequest(), path, checksum, "sha256")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	data, _ := os.ReadFile(path)
+	assert.Equal(t, content, data)
+
+	// the complete file is only verified
+	resp, err = client.DownloadVerified(newRequest(), path, checksum, "SHA256")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, resp.StatusCode)
+
+	// a partial file is resumed
+	assert.NoError(t, os.WriteFile(path, content[:1234], 0o644))
+	resp, err = client.DownloadVerified(newRequest(), path, checksum, "sha256")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	data, _ = os.ReadFile(path)
+	assert.Equal(t, content, data)
+
+	assert.Equal(t, []string{"", "bytes=10000-", "bytes=1234-"}, ranges)
+
+	// a mismatched file is deleted
+	_, err = client.DownloadVerified(newRequest(), path+".bad", checksum[1:]+"0", "sha256")
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+	assert.NoFileExists(t, path+".bad")
+
+	_, err = client.DownloadVerified(newRequest(), path, checksum, "crc32")
+	assert.ErrorIs(t, err, ErrUnsupportedHashAlgorithm)
+}
+
+func TestClient_DownloadVerifiedStatus(t *testing.T) {
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	client := New(WithBaseUrl(server.URL))
+	path := filepath.Join(t.TempDir(), "partial.bin")
+	assert.NoError(t, os.WriteFile(path, []byte("partial"), 0o644))
+
+	resp, err := client.DownloadVerified(request.NewRequest(context.Background(), "/missing"), path, "", "md5")
+	assert.ErrorIs(t, err, ErrUnexpectedStatus)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	data, _ := os.ReadFile(path)
+	assert.Equal(t, "partial", string(data))
+}
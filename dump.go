@@ -0,0 +1,57 @@
+package apik
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httputil"
+)
+
+// RequestDump returns the final http.Request, as it was sent after redirects, in its HTTP/1.1 wire representation.
+// Headers, including cookies from the jar, and the body are passed through the redactor of the client.
+// The body is included only if it can be obtained again, e.g. it is not a one-shot reader.
+func (r *Response) RequestDump() ([]byte, error) {
+	if r.Raw == nil || r.Raw.Request == nil {
+		return nil, ErrNoRequest
+	}
+	req := r.Raw.Request.Clone(context.Background())
+
+	var body []byte
+	if r.Raw.Request.GetBody != nil {
+		rc, err := r.Raw.Request.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		body, err = io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if r.redactor != nil {
+		body = r.redactor(req.Header, body)
+	}
+
+	req.Body = nil
+	req.ContentLength = int64(len(body))
+	if len(body) > 0 {
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return httputil.DumpRequestOut(req, true)
+}
+
+// ResponseDump returns the status line and headers of the response in their HTTP/1.1 wire representation.
+// Headers are passed through the redactor of the client. The body is not included, it is consumed by the client.
+func (r *Response) ResponseDump() ([]byte, error) {
+	if r.Raw == nil {
+		return nil, ErrNoResponse
+	}
+	resp := *r.Raw
+	resp.Header = r.Raw.Header.Clone()
+	if r.redactor != nil {
+		r.redactor(resp.Header, nil)
+	}
+	resp.Body = http.NoBody
+	return httputil.DumpResponse(&resp, false)
+}
@@ -0,0 +1,55 @@
+package apik
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/niklak/apik/reqopt"
+	"github.com/niklak/apik/request"
+	"github.com/niklak/httpbulb"
+)
+
+func TestResponse_Dump(t *testing.T) {
+	testServer := httptest.NewServer(httpbulb.NewRouter())
+	defer testServer.Close()
+
+	client := New(
+		WithBaseUrl(testServer.URL),
+		WithCookies([]*http.Cookie{{Name: "session", Value: "secret-session"}}),
+	)
+
+	resp, err := client.Fetch(
+		request.NewRequest(
+			context.Background(),
+			"/post",
+			reqopt.Method(http.MethodPost),
+			reqopt.Header("Authorization", "Bearer secret-token"),
+			reqopt.SetJSON(map[string]string{"user": "admin", "password": "secret-password"}),
+		),
+		nil,
+	)
+	require.NoError(t, err)
+
+	dump, err := resp.RequestDump()
+	require.NoError(t, err)
+	assert.Contains(t, string(dump), "POST /post HTTP/1.1\r\n")
+	assert.Contains(t, string(dump), "Authorization: [REDACTED]\r\n")
+	assert.Contains(t, string(dump), "Cookie: [REDACTED]\r\n")
+	assert.Contains(t, string(dump), `"user":"admin"`)
+	assert.NotContains(t, string(dump), "secret-")
+
+	dump, err = resp.ResponseDump()
+	require.NoError(t, err)
+	assert.Contains(t, string(dump), "HTTP/1.1 200 OK\r\n")
+	assert.Contains(t, string(dump), "Content-Type: application/json")
+
+	_, err = new(Response).RequestDump()
+	assert.ErrorIs(t, err, ErrNoRequest)
+	_, err = new(Response).ResponseDump()
+	assert.ErrorIs(t, err, ErrNoResponse)
+}
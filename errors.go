@@ -0,0 +1,132 @@
+package apik
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+)
+
+var (
+	ErrUnsupportedDigestAlgorithm = errors.New("unsupported digest algorithm")
+	ErrCircuitOpen                = errors.New("circuit breaker is open")
+	ErrUnsupportedHashAlgorithm   = errors.New("unsupported hash algorithm")
+	ErrChecksumMismatch           = errors.New("checksum mismatch")
+	ErrUnexpectedStatus           = errors.New("unexpected status code")
+	ErrNotMultipart               = errors.New("response is not a multipart")
+	ErrBlockedHost                = errors.New("host is blocked by the host policy")
+	ErrResultNotPointer           = errors.New("result must be a non-nil pointer")
+	ErrNoRequest                  = errors.New("response has no request")
+	ErrNoResponse                 = errors.New("response has no raw response")
+	ErrMissingField               = errors.New("missing field in the response")
+	ErrNoProxies                  = errors.New("no proxies to rotate")
+	ErrMissingHeader              = errors.New("missing required response header")
+	ErrIdleTimeout                = errors.New("no response body bytes within the idle timeout")
+	ErrTrailingData               = errors.New("unexpected data after the JSON value")
+	ErrFingerprintProxy           = errors.New("TLS fingerprint can't be applied through a proxy")
+)
+
+// ErrorKind represents a category of the request error
+type ErrorKind int
+
+const (
+	// KindUnknown is an error that does not fit into other categories
+	KindUnknown ErrorKind = iota
+	// KindTimeout is a timeout of the request, the connection or the context deadline
+	KindTimeout
+	// KindDNS is a failure to resolve the host name
+	KindDNS
+	// KindConnection is a failure to establish or keep the connection (refused, reset, etc.)
+	KindConnection
+	// KindTLS is a TLS handshake or certificate verification failure
+	KindTLS
+	// KindCanceled is a cancellation of the request context
+	KindCanceled
+	// KindHTTP is an error status code of the response
+	KindHTTP
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case KindTimeout:
+		return "timeout"
+	case KindDNS:
+		return "dns"
+	case KindConnection:
+		return "connection"
+	case KindTLS:
+		return "tls"
+	case KindCanceled:
+		return "canceled"
+	case KindHTTP:
+		return "http"
+	default:
+		return "unknown"
+	}
+}
+
+// RequestError is returned by the Client when the request could not be completed.
+// The original error is accessible with errors.Unwrap, errors.Is and errors.As.
+type RequestError struct {
+	Kind ErrorKind
+	Err  error
+}
+
+func (e *RequestError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// IsErrorKind reports whether any error in err's chain is a *RequestError of the given kind
+func IsErrorKind(err error, kind ErrorKind) bool {
+	var reqErr *RequestError
+	return errors.As(err, &reqErr) && reqErr.Kind == kind
+}
+
+// newRequestError wraps the error into a *RequestError with the classified kind
+func newRequestError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		return err
+	}
+	return &RequestError{Kind: classifyError(err), Err: err}
+}
+
+// classifyError determines the kind of the error returned by the http.Client
+func classifyError(err error) ErrorKind {
+	var (
+		netErr         net.Error
+		dnsErr         *net.DNSError
+		opErr          *net.OpError
+		recordErr      tls.RecordHeaderError
+		alertErr       tls.AlertError
+		certVerifyErr  *tls.CertificateVerificationError
+		unknownAuthErr x509.UnknownAuthorityError
+		hostnameErr    x509.HostnameError
+		certInvalidErr x509.CertificateInvalidError
+	)
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return KindCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return KindTimeout
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return KindTimeout
+	case errors.As(err, &dnsErr):
+		return KindDNS
+	case errors.As(err, &certVerifyErr), errors.As(err, &unknownAuthErr), errors.As(err, &hostnameErr),
+		errors.As(err, &certInvalidErr), errors.As(err, &recordErr), errors.As(err, &alertErr):
+		return KindTLS
+	case errors.As(err, &opErr):
+		return KindConnection
+	}
+	return KindUnknown
+}
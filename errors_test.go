@@ -0,0 +1,64 @@
+package apik
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/niklak/apik/request"
+)
+
+func TestClient_ErrorKind(t *testing.T) {
+
+	slowServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer slowServer.Close()
+
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer tlsServer.Close()
+
+	closedServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closedServer.Close()
+
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	timeoutCtx, cancelTimeout := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelTimeout()
+
+	tests := []struct {
+		name string
+		req  *request.Request
+		kind ErrorKind
+	}{
+		{"timeout", request.NewRequest(timeoutCtx, slowServer.URL), KindTimeout},
+		{"canceled", request.NewRequest(canceledCtx, slowServer.URL), KindCanceled},
+		{"tls", request.NewRequest(context.Background(), tlsServer.URL), KindTLS},
+		{"connection", request.NewRequest(context.Background(), closedServer.URL), KindConnection},
+		{"dns", request.NewRequest(context.Background(), "http://apik.invalid"), KindDNS},
+	}
+
+	client := New()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.Fetch(tt.req, nil)
+			assert.Error(t, err)
+
+			var reqErr *RequestError
+			assert.True(t, errors.As(err, &reqErr))
+			assert.Equal(t, tt.kind, reqErr.Kind, err.Error())
+			assert.True(t, IsErrorKind(err, tt.kind))
+			assert.NotNil(t, errors.Unwrap(err))
+		})
+	}
+}
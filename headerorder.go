@@ -0,0 +1,151 @@
+package apik
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"slices"
+	"sync"
+	"time"
+)
+
+// WithHeaderOrder makes the client write request headers in the given order, instead of the sorted one of net/http.
+// Headers that are not in the list follow the listed ones in their usual order. The list may contain `Host`.
+//
+// Headers are reordered on the wire, so the option replaces the dialers of the transport
+// and works only if the transport is *http.Transport. Deterministic ordering is possible only with HTTP/1.1,
+// so requests are sent over HTTP/1.1 even to servers that support HTTP/2.
+// The transport does not see the TLS connection state then, so http.Response.TLS is nil.
+// HTTPS requests through a proxy are sent in the usual order.
+func WithHeaderOrder(order []string) ClientOption {
+	return func(c *Client) {
+		c.headerOrder = make(map[string]int, len(order))
+		for i, key := range order {
+			c.headerOrder[http.CanonicalHeaderKey(key)] = i
+		}
+	}
+}
+
+// orderHeaders wraps connections of the transport, so request headers written to them are reordered.
+// It must be applied after other transport options, to wrap the final dialer.
+func (c *Client) orderHeaders(t *http.Transport) {
+	dial := t.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	}
+	t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return &orderedConn{Conn: conn, order: c.headerOrder}, nil
+	}
+	t.ForceAttemptHTTP2 = false
+
+	if dialTLS := t.DialTLSContext; dialTLS != nil {
+		// e.g. the dialer of WithUTLSFingerprint
+		t.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := dialTLS(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			return &orderedConn{Conn: conn, order: c.headerOrder}, nil
+		}
+		return
+	}
+
+	tlsConfig := t.TLSClientConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+	t.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		cfg := tlsConfig.Clone()
+		if cfg.ServerName == "" {
+			cfg.ServerName, _, _ = net.SplitHostPort(addr)
+		}
+		// the transport speaks HTTP/2 only over *tls.Conn, so the server must not choose it
+		cfg.NextProtos = []string{"http/1.1"}
+		tlsConn := tls.Client(conn, cfg)
+		if err = tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return &orderedConn{Conn: tlsConn, order: c.headerOrder}, nil
+	}
+}
+
+// withHeaderOrder returns a context, that makes the connection of the request expect a request head to reorder
+func withHeaderOrder(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if conn, ok := info.Conn.(*orderedConn); ok {
+				conn.expectHead()
+			}
+		},
+	})
+}
+
+// orderedConn is a connection that reorders the headers of the request head written to it.
+// The transport writes the next request to the connection only after it was obtained for the request,
+// so the head is expected after GotConn.
+type orderedConn struct {
+	net.Conn
+	order map[string]int
+
+	mu     sync.Mutex
+	inHead bool
+	head   []byte
+}
+
+func (c *orderedConn) expectHead() {
+	c.mu.Lock()
+	c.inHead = true
+	c.head = c.head[:0]
+	c.mu.Unlock()
+}
+
+// Write buffers the request head until it is complete, then writes it with the reordered headers
+func (c *orderedConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.inHead {
+		return c.Conn.Write(p)
+	}
+	c.head = append(c.head, p...)
+	end := bytes.Index(c.head, []byte("\r\n\r\n"))
+	if end < 0 {
+		return len(p), nil
+	}
+	c.inHead = false
+	data := append(reorderHead(c.head[:end], c.order), c.head[end:]...)
+	if _, err := c.Conn.Write(data); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// reorderHead sorts the header lines of the request head by their position in the order.
+// The request line stays first, lines of the same rank keep their relative order.
+func reorderHead(head []byte, order map[string]int) []byte {
+	lines := bytes.Split(head, []byte("\r\n"))
+	rank := func(line []byte) int {
+		key, _, _ := bytes.Cut(line, []byte(":"))
+		if i, ok := order[http.CanonicalHeaderKey(string(key))]; ok {
+			return i
+		}
+		return len(order)
+	}
+	headers := lines[1:]
+	slices.SortStableFunc(headers, func(a, b []byte) int {
+		return rank(a) - rank(b)
+	})
+	return bytes.Join(lines, []byte("\r\n"))
+}
@@ -0,0 +1,137 @@
+package apik
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/niklak/apik/reqopt"
+	"github.com/niklak/apik/request"
+	"github.com/niklak/httpbulb"
+)
+
+// rawHeaderServer records the header names of every request in the order they were received
+func rawHeaderServer(t *testing.T) (addr string, heads func() [][]string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	var mu sync.Mutex
+	var received [][]string
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				for {
+					if _, err := reader.ReadString('\n'); err != nil {
+						return
+					}
+					var names []string
+					for {
+						line, err := reader.ReadString('\n')
+						if err != nil {
+							return
+						}
+						if line = strings.TrimSpace(line); line == "" {
+							break
+						}
+						name, _, _ := strings.Cut(line, ":")
+						names = append(names, name)
+					}
+					mu.Lock()
+					received = append(received, names)
+					mu.Unlock()
+					conn.Write([]byte("HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok"))
+				}
+			}()
+		}
+	}()
+
+	return ln.Addr().String(), func() [][]string {
+		mu.Lock()
+		defer mu.Unlock()
+		return received
+	}
+}
+
+func TestClient_HeaderOrder(t *testing.T) {
+	addr, heads := rawHeaderServer(t)
+
+	client := New(
+		WithBaseUrl("http://"+addr),
+		WithHeader("X-Client", "1"),
+		WithHeaderOrder([]string{"user-agent", "Accept", "Host", "X-Custom"}),
+	)
+
+	for i := 0; i < 2; i++ {
+		var body string
+		_, err := client.Fetch(
+			request.NewRequest(context.Background(), "/", reqopt.Accept("*/*"), reqopt.Header("X-Custom", "1")),
+			&body,
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "ok", body)
+	}
+
+	received := heads()
+	require.Len(t, received, 2)
+	for _, names := range received {
+		assert.Equal(t, []string{"User-Agent", "Accept", "Host", "X-Custom", "X-Client", "Accept-Encoding"}, names)
+	}
+}
+
+func TestReorderHead(t *testing.T) {
+	head := "GET / HTTP/1.1\r\nHost: example.com\r\nA: 1\r\nB: 1\r\nB: 2\r\nC: 1"
+	order := map[string]int{"B": 0, "Host": 1}
+	assert.Equal(t,
+		"GET / HTTP/1.1\r\nB: 1\r\nB: 2\r\nHost: example.com\r\nA: 1\r\nC: 1",
+		string(reorderHead([]byte(head), order)),
+	)
+}
+
+func TestClient_HeaderOrderTLS(t *testing.T) {
+	testServer := httptest.NewUnstartedServer(httpbulb.NewRouter())
+	testServer.EnableHTTP2 = true
+	testServer.StartTLS()
+	defer testServer.Close()
+
+	client := New(
+		WithBaseUrl(testServer.URL),
+		WithHttpClient(testServer.Client()),
+		WithHeaderOrder([]string{"Accept"}),
+	)
+
+	resp, err := client.Fetch(request.NewRequest(context.Background(), "/get"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, 1, resp.Raw.ProtoMajor)
+}
+
+func TestClient_BrowserHeaderOrder(t *testing.T) {
+	addr, heads := rawHeaderServer(t)
+
+	client := New(
+		WithBaseUrl("http://"+addr),
+		WithBrowserHeaders("firefox"),
+		WithHeaderOrder(BrowserHeaderOrder("firefox")),
+	)
+	_, err := client.Fetch(request.NewRequest(context.Background(), "/"), nil)
+	require.NoError(t, err)
+
+	received := heads()
+	require.Len(t, received, 1)
+	assert.Equal(t, append(BrowserHeaderOrder("firefox"), "Accept-Encoding"), received[0])
+	assert.Nil(t, BrowserHeaderOrder("netscape"))
+}
@@ -0,0 +1,63 @@
+package apik
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+)
+
+var (
+	errPrivateAddr = errors.New("address is not public")
+	// nonPublicPrefixes are the special-purpose ranges, that are not covered by the netip.Addr methods
+	nonPublicPrefixes = []netip.Prefix{
+		netip.MustParsePrefix("0.0.0.0/8"),     // "this network" (RFC 791)
+		netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT (RFC 6598)
+		netip.MustParsePrefix("192.0.0.0/24"),  // IETF protocol assignments (RFC 6890)
+		netip.MustParsePrefix("198.18.0.0/15"), // benchmarking (RFC 2544)
+		netip.MustParsePrefix("240.0.0.0/4"),   // reserved, including the broadcast address (RFC 1112)
+	}
+	// nat64Prefix is the well-known NAT64 prefix (RFC 6052), the IPv4 address is in the last 4 bytes
+	nat64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+	// sixToFourPrefix is the 6to4 prefix (RFC 3056), the IPv4 address follows the first 2 bytes
+	sixToFourPrefix = netip.MustParsePrefix("2002::/16")
+)
+
+// DenyPrivateAddr is a host policy for WithHostPolicy, that denies connections to non-public addresses:
+// loopback, private, shared (CGNAT), link-local (including the cloud metadata 169.254.169.254),
+// unspecified, multicast, reserved and other special-purpose ones.
+// IPv4 addresses embedded into NAT64 and 6to4 addresses are checked as well.
+// Use it for server-side fetching of user-provided URLs to prevent SSRF.
+func DenyPrivateAddr(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	ip = ip.Unmap()
+	if ip.Is6() && (nat64Prefix.Contains(ip) || sixToFourPrefix.Contains(ip)) {
+		ip = embeddedIPv4(ip)
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return errPrivateAddr
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(ip) {
+			return errPrivateAddr
+		}
+	}
+	return nil
+}
+
+// embeddedIPv4 returns the IPv4 address embedded into a NAT64 or 6to4 address
+func embeddedIPv4(ip netip.Addr) netip.Addr {
+	b := ip.As16()
+	if sixToFourPrefix.Contains(ip) {
+		return netip.AddrFrom4([4]byte{b[2], b[3], b[4], b[5]})
+	}
+	return netip.AddrFrom4([4]byte{b[12], b[13], b[14], b[15]})
+}
@@ -0,0 +1,106 @@
+package apik
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/niklak/apik/request"
+)
+
+func TestClient_HostPolicy(t *testing.T) {
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer testServer.Close()
+
+	client := New(WithBaseUrl(testServer.URL), WithHostPolicy(DenyPrivateAddr))
+
+	_, err := client.Fetch(request.NewRequest(context.Background(), "/"), nil)
+	assert.ErrorIs(t, err, ErrBlockedHost)
+
+	// the policy works along with the dial control, in any order
+	var checked []string
+	client = New(
+		WithBaseUrl(testServer.URL),
+		WithHostPolicy(func(addr string) error {
+			checked = append(checked, addr)
+			return nil
+		}),
+		WithDialControl(time.Second, true),
+		WithTrace(),
+	)
+
+	req := request.NewRequest(context.Background(), "/")
+	_, err = client.Fetch(req, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{testServer.Listener.Addr().String()}, checked)
+	assert.Equal(t, "tcp4", req.TraceInfo().ConnectStart[0].Network)
+
+	// denied hosts are not retried
+	var denials int
+	client = New(
+		WithBaseUrl(testServer.URL),
+		WithHostPolicy(func(addr string) error {
+			denials++
+			return DenyPrivateAddr(addr)
+		}),
+		WithRetry(3, func(int) time.Duration { return 0 }),
+	)
+	_, err = client.Fetch(request.NewRequest(context.Background(), "/"), nil)
+	assert.ErrorIs(t, err, ErrBlockedHost)
+	assert.Equal(t, 1, denials)
+}
+
+// roundTripperFunc is a custom http.RoundTripper, that can't be configured by the client
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestClient_HostPolicyCustomTransport(t *testing.T) {
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer testServer.Close()
+
+	// the policy can't be installed, so requests fail instead of bypassing it
+	rt := roundTripperFunc(http.DefaultTransport.RoundTrip)
+	client := New(
+		WithBaseUrl(testServer.URL),
+		WithHttpClient(&http.Client{Transport: rt}),
+		WithHostPolicy(DenyPrivateAddr),
+	)
+
+	_, err := client.Fetch(request.NewRequest(context.Background(), "/"), nil)
+	assert.ErrorIs(t, err, ErrBlockedHost)
+	assert.ErrorContains(t, err, "host policy unsupported with apik.roundTripperFunc")
+
+	rawReq, _ := http.NewRequest(http.MethodGet, testServer.URL, nil)
+	_, err = client.DoRaw(rawReq)
+	assert.ErrorIs(t, err, ErrBlockedHost)
+}
+
+func TestDenyPrivateAddr(t *testing.T) {
+
+	denied := []string{
+		"127.0.0.1:80", "[::1]:443", "10.1.2.3:80", "172.16.0.1:80", "192.168.1.1:80",
+		"169.254.169.254:80", "100.64.0.1:80", "0.0.0.0:80", "[::ffff:127.0.0.1]:80", "[fe80::1%eth0]:80", "fd00::1",
+		"0.1.2.3:80", "192.0.0.170:80", "198.18.0.1:80", "240.0.0.1:80", "255.255.255.255:80",
+		// NAT64 and 6to4 addresses of 169.254.169.254 and 127.0.0.1
+		"[64:ff9b::a9fe:a9fe]:80", "[2002:7f00:1::1]:80",
+	}
+	for _, addr := range denied {
+		assert.Error(t, DenyPrivateAddr(addr), addr)
+	}
+
+	allowed := []string{
+		"93.184.216.34:443", "[2606:2800:220:1:248:1893:25c8:1946]:80", "8.8.8.8", "[64:ff9b::808:808]:80",
+	}
+	for _, addr := range allowed {
+		assert.NoError(t, DenyPrivateAddr(addr), addr)
+	}
+}
@@ -0,0 +1,78 @@
+package apik
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// defaultErrorBodyLimit is the default maximum size of the response body captured by HTTPError
+const defaultErrorBodyLimit = 4 << 10
+
+// HTTPError is returned, wrapped into a *RequestError of KindHTTP, for responses with an error status code
+// if the client is created with WithFailOnError. The response body is read and closed before it is returned.
+// It matches ErrUnexpectedStatus with errors.Is.
+type HTTPError struct {
+	StatusCode int
+	// Status is the status line of the response, e.g. "404 Not Found"
+	Status string
+	// Header is the response header, passed through the redactor of the client
+	Header http.Header
+	// Body is the beginning of the response body, up to the limit of WithErrorBodyLimit,
+	// passed through the redactor of the client
+	Body []byte
+	// Truncated reports whether the body was longer than the limit
+	Truncated bool
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrUnexpectedStatus, e.Status)
+}
+
+func (e *HTTPError) Unwrap() error {
+	return ErrUnexpectedStatus
+}
+
+// Decode unmarshals the captured JSON body into v, e.g. an error struct of the API
+func (e *HTTPError) Decode(v any) error {
+	return json.Unmarshal(e.Body, v)
+}
+
+// WithFailOnError makes the client return an error for responses with status codes 400 and above,
+// instead of the response. The error is a *RequestError of KindHTTP, that wraps an *HTTPError
+// with the status, the header and the beginning of the body. Retries are made before the status is checked.
+func WithFailOnError() ClientOption {
+	return func(c *Client) {
+		c.failOnError = true
+	}
+}
+
+// WithErrorBodyLimit sets the maximum size of the response body captured by HTTPError. Default is 4KB.
+func WithErrorBodyLimit(limit int64) ClientOption {
+	return func(c *Client) {
+		c.errorBodyLimit = limit
+	}
+}
+
+// newHTTPError reads the beginning of the response body into a *HTTPError and closes the body
+func (c *Client) newHTTPError(resp *http.Response) error {
+	defer drainAndClose(resp.Body)
+
+	limit := c.errorBodyLimit
+	if limit <= 0 {
+		limit = defaultErrorBodyLimit
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return newRequestError(err)
+	}
+
+	httpErr := &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status}
+	if int64(len(body)) > limit {
+		body, httpErr.Truncated = body[:limit], true
+	}
+	httpErr.Header = resp.Header.Clone()
+	httpErr.Body = c.redactor(httpErr.Header, body)
+	return &RequestError{Kind: KindHTTP, Err: httpErr}
+}
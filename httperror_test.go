@@ -0,0 +1,87 @@
+package apik
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/niklak/apik/request"
+)
+
+func TestClient_FailOnError(t *testing.T) {
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.Write([]byte("ok"))
+		case "/large":
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(strings.Repeat("x", 100)))
+		case "/token":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"token":"secret","message":"expired"}`))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Set-Cookie", "session=secret")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"not_found","message":"no such user"}`))
+		}
+	}))
+	defer testServer.Close()
+
+	client := New(WithBaseUrl(testServer.URL), WithFailOnError())
+
+	resp, err := client.Fetch(request.NewRequest(context.Background(), "/ok"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result map[string]any
+	resp, err = client.JSON(request.NewRequest(context.Background(), "/users/1"), &result)
+	assert.Nil(t, resp)
+	assert.Nil(t, result)
+	assert.True(t, IsErrorKind(err, KindHTTP))
+	assert.ErrorIs(t, err, ErrUnexpectedStatus)
+	assert.EqualError(t, err, "unexpected status code: 404 Not Found")
+
+	var httpErr *HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusNotFound, httpErr.StatusCode)
+	assert.Equal(t, "application/json", httpErr.Header.Get("Content-Type"))
+	assert.Equal(t, redactedValue, httpErr.Header.Get("Set-Cookie"))
+	assert.False(t, httpErr.Truncated)
+
+	var apiErr struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	require.NoError(t, httpErr.Decode(&apiErr))
+	assert.Equal(t, "not_found", apiErr.Code)
+	assert.Equal(t, "no such user", apiErr.Message)
+
+	// the captured body is passed through the redactor
+	_, err = client.Fetch(request.NewRequest(context.Background(), "/token"), nil)
+	require.ErrorAs(t, err, &httpErr)
+	assert.NotContains(t, string(httpErr.Body), "secret")
+	assert.Contains(t, string(httpErr.Body), "expired")
+
+	// the captured body is limited
+	client = New(WithBaseUrl(testServer.URL), WithFailOnError(), WithErrorBodyLimit(10))
+	_, err = client.Fetch(request.NewRequest(context.Background(), "/large"), nil)
+	require.True(t, errors.As(err, &httpErr))
+	assert.Equal(t, http.StatusInternalServerError, httpErr.StatusCode)
+	assert.Equal(t, []byte("xxxxxxxxxx"), httpErr.Body)
+	assert.True(t, httpErr.Truncated)
+
+	// without the option, error statuses are returned as responses
+	client = New(WithBaseUrl(testServer.URL))
+	resp, err = client.Fetch(request.NewRequest(context.Background(), "/large"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+}
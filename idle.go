@@ -0,0 +1,64 @@
+package apik
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// idleTimeout cancels the request context, if no bytes of the response body arrive for the timeout
+type idleTimeout struct {
+	timeout time.Duration
+	cancel  context.CancelFunc
+	timer   *time.Timer
+	expired atomic.Bool
+}
+
+// withIdleTimeout returns a copy of the Request with a context, that is cancelled by the idle timeout
+func withIdleTimeout(req *Request) (*Request, *idleTimeout) {
+	r := req.Clone()
+	idle := &idleTimeout{timeout: req.IdleTimeout}
+	r.Ctx, idle.cancel = context.WithCancel(req.Ctx)
+	return r, idle
+}
+
+// watch starts the idle timer for the response body, or releases the context if there is no response
+func (t *idleTimeout) watch(resp *http.Response) *http.Response {
+	if resp == nil {
+		t.cancel()
+		return nil
+	}
+	t.timer = time.AfterFunc(t.timeout, func() {
+		t.expired.Store(true)
+		t.cancel()
+	})
+	resp.Body = &idleReader{ReadCloser: resp.Body, idle: t}
+	return resp
+}
+
+// idleReader resets the idle timer on every read of the body
+type idleReader struct {
+	io.ReadCloser
+	idle *idleTimeout
+}
+
+func (r *idleReader) Read(p []byte) (n int, err error) {
+	n, err = r.ReadCloser.Read(p)
+	if n > 0 {
+		r.idle.timer.Reset(r.idle.timeout)
+	}
+	if err != nil && !errors.Is(err, io.EOF) && r.idle.expired.Load() {
+		err = &RequestError{Kind: KindTimeout, Err: ErrIdleTimeout}
+	}
+	return
+}
+
+func (r *idleReader) Close() error {
+	r.idle.timer.Stop()
+	err := r.ReadCloser.Close()
+	r.idle.cancel()
+	return err
+}
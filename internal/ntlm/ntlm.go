@@ -0,0 +1,188 @@
+// Package ntlm implements the client side of the NTLMv2 authentication handshake (MS-NLMP).
+package ntlm
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"golang.org/x/crypto/md4"
+)
+
+var ErrInvalidChallenge = errors.New("invalid NTLM challenge message")
+
+var signature = []byte("NTLMSSP\x00")
+
+const (
+	negotiateUnicode                 = 0x00000001
+	negotiateOEM                     = 0x00000002
+	requestTarget                    = 0x00000004
+	negotiateNTLM                    = 0x00000200
+	negotiateAlwaysSign              = 0x00008000
+	negotiateExtendedSessionSecurity = 0x00080000
+
+	negotiateFlags = negotiateUnicode | negotiateOEM | requestTarget | negotiateNTLM |
+		negotiateAlwaysSign | negotiateExtendedSessionSecurity
+)
+
+const (
+	avIDMsvAvEOL       = 0
+	avIDMsvAvTimestamp = 7
+)
+
+// Challenge represents the CHALLENGE_MESSAGE sent by the server
+type Challenge struct {
+	Flags           uint32
+	ServerChallenge []byte
+	TargetInfo      []byte
+}
+
+// NegotiateMessage returns the NEGOTIATE_MESSAGE, the first message of the handshake
+func NegotiateMessage() []byte {
+	msg := make([]byte, 32)
+	copy(msg, signature)
+	binary.LittleEndian.PutUint32(msg[8:], 1)
+	binary.LittleEndian.PutUint32(msg[12:], negotiateFlags)
+	return msg
+}
+
+// ParseChallenge parses the CHALLENGE_MESSAGE
+func ParseChallenge(msg []byte) (ch *Challenge, err error) {
+	if len(msg) < 48 || !bytes.Equal(msg[:8], signature) || binary.LittleEndian.Uint32(msg[8:]) != 2 {
+		return nil, ErrInvalidChallenge
+	}
+	ch = &Challenge{
+		Flags:           binary.LittleEndian.Uint32(msg[20:]),
+		ServerChallenge: msg[24:32],
+	}
+	if ch.TargetInfo, err = readField(msg, 40); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// AuthenticateMessage returns the AUTHENTICATE_MESSAGE with the NTLMv2 response to the challenge
+func AuthenticateMessage(ch *Challenge, domain, user, password string) ([]byte, error) {
+	clientChallenge := make([]byte, 8)
+	if _, err := rand.Read(clientChallenge); err != nil {
+		return nil, err
+	}
+
+	timestamp, hasTimestamp := avTimestamp(ch.TargetInfo)
+	if !hasTimestamp {
+		timestamp = fileTime(time.Now())
+	}
+
+	key := NTOWFv2(password, user, domain)
+
+	temp := make([]byte, 0, 32+len(ch.TargetInfo))
+	temp = append(temp, 1, 1, 0, 0, 0, 0, 0, 0)
+	temp = binary.LittleEndian.AppendUint64(temp, timestamp)
+	temp = append(temp, clientChallenge...)
+	temp = append(temp, 0, 0, 0, 0)
+	temp = append(temp, ch.TargetInfo...)
+	temp = append(temp, 0, 0, 0, 0)
+
+	ntProof := hmacMD5(key, ch.ServerChallenge, temp)
+	ntResponse := append(ntProof, temp...)
+
+	// If the server provides a timestamp, the LM response must be zeroed
+	lmResponse := make([]byte, 24)
+	if !hasTimestamp {
+		lmResponse = append(hmacMD5(key, ch.ServerChallenge, clientChallenge), clientChallenge...)
+	}
+
+	fields := [][]byte{
+		lmResponse,
+		ntResponse,
+		encodeUTF16(domain),
+		encodeUTF16(user),
+		nil, // workstation
+		nil, // encrypted random session key
+	}
+
+	msg := make([]byte, 64)
+	copy(msg, signature)
+	binary.LittleEndian.PutUint32(msg[8:], 3)
+	binary.LittleEndian.PutUint32(msg[60:], ch.Flags&negotiateFlags|negotiateUnicode)
+
+	offset := len(msg)
+	for i, field := range fields {
+		pos := 12 + i*8
+		binary.LittleEndian.PutUint16(msg[pos:], uint16(len(field)))
+		binary.LittleEndian.PutUint16(msg[pos+2:], uint16(len(field)))
+		binary.LittleEndian.PutUint32(msg[pos+4:], uint32(offset))
+		offset += len(field)
+	}
+	for _, field := range fields {
+		msg = append(msg, field...)
+	}
+	return msg, nil
+}
+
+// NTOWFv2 computes the NTLMv2 response key from the user credentials
+func NTOWFv2(password, user, domain string) []byte {
+	return hmacMD5(ntHash(password), encodeUTF16(strings.ToUpper(user)+domain))
+}
+
+// ntHash returns the NT hash of the password: MD4 of the UTF-16LE encoded password.
+// MD4 is obsolete, but it is still required by NTLM.
+func ntHash(password string) []byte {
+	h := md4.New()
+	h.Write(encodeUTF16(password))
+	return h.Sum(nil)
+}
+
+func hmacMD5(key []byte, data ...[]byte) []byte {
+	mac := hmac.New(md5.New, key)
+	for _, d := range data {
+		mac.Write(d)
+	}
+	return mac.Sum(nil)
+}
+
+func encodeUTF16(s string) []byte {
+	u := utf16.Encode([]rune(s))
+	b := make([]byte, len(u)*2)
+	for i, v := range u {
+		binary.LittleEndian.PutUint16(b[i*2:], v)
+	}
+	return b
+}
+
+// readField reads a variable-length field, described at pos of the message
+func readField(msg []byte, pos int) ([]byte, error) {
+	length := int(binary.LittleEndian.Uint16(msg[pos:]))
+	offset := int(binary.LittleEndian.Uint32(msg[pos+4:]))
+	if offset+length > len(msg) {
+		return nil, ErrInvalidChallenge
+	}
+	return msg[offset : offset+length], nil
+}
+
+// avTimestamp looks for the MsvAvTimestamp pair in the target info
+func avTimestamp(info []byte) (uint64, bool) {
+	for len(info) >= 4 {
+		id := binary.LittleEndian.Uint16(info)
+		length := int(binary.LittleEndian.Uint16(info[2:]))
+		if id == avIDMsvAvEOL || len(info) < 4+length {
+			break
+		}
+		if id == avIDMsvAvTimestamp && length == 8 {
+			return binary.LittleEndian.Uint64(info[4:]), true
+		}
+		info = info[4+length:]
+	}
+	return 0, false
+}
+
+// fileTime converts time to the Windows FILETIME: 100-nanosecond intervals since January 1, 1601
+func fileTime(t time.Time) uint64 {
+	return uint64(t.UnixNano()/100) + 116444736000000000
+}
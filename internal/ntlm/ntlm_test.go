@@ -0,0 +1,14 @@
+package ntlm
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNTOWFv2(t *testing.T) {
+	// test vectors from MS-NLMP 4.2.1 and 4.2.4.1.1
+	assert.Equal(t, "a4f49c406510bdcab6824ee7c30fd852", hex.EncodeToString(ntHash("Password")))
+	assert.Equal(t, "0c868a403bfd7a93a3001ef22ef02e3f", hex.EncodeToString(NTOWFv2("Password", "User", "Domain")))
+}
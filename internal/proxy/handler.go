@@ -1,14 +1,38 @@
 package proxy
 
 import (
+	"context"
+	"crypto/subtle"
 	"net"
 	"net/http"
-	"sync"
+	"net/http/httputil"
 
 	"github.com/rs/zerolog/log"
 )
 
 func HttpProxyConnectHandler(w http.ResponseWriter, r *http.Request) {
+	ConnectHandler(context.Background())(w, r)
+}
+
+// ConnectHandler returns a CONNECT handler, whose tunnels are shut down when the context is done, see tunnel.
+// Hijacked connections are not tracked by http.Server, so the context is the only way to stop them.
+// Plain HTTP requests with an absolute URI are forwarded to their targets, they are canceled when the context is done.
+func ConnectHandler(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		serveConnect(ctx, w, r)
+	}
+}
+
+func serveConnect(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodConnect && r.URL.IsAbs() {
+		// the forwarded request is canceled when the proxy is shut down, as well as by the client
+		reqCtx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		stop := context.AfterFunc(ctx, cancel)
+		defer stop()
+		forwardProxy.ServeHTTP(w, r.WithContext(reqCtx))
+		return
+	}
 	if r.Method != http.MethodConnect {
 		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
 		return
@@ -25,7 +49,7 @@ func HttpProxyConnectHandler(w http.ResponseWriter, r *http.Request) {
 
 	logger.Debug().Msgf("Proxy %s %s", r.Method, r.URL.Host)
 
-	targetConn, err := net.Dial("tcp", r.URL.Host)
+	targetConn, err := (&net.Dialer{}).DialContext(ctx, "tcp", r.URL.Host)
 	if err != nil {
 		writeStatusLine(clientConn, http.StatusServiceUnavailable, r.Proto)
 		return
@@ -36,11 +60,38 @@ func HttpProxyConnectHandler(w http.ResponseWriter, r *http.Request) {
 
 	logger.Debug().Msg("Transfer start")
 
-	wg := &sync.WaitGroup{}
-	wg.Add(2)
-	go copyIO(wg, targetConn, clientConn)
-	go copyIO(wg, clientConn, targetConn)
-	wg.Wait()
+	tunnel(ctx, clientConn, targetConn)
 
 	logger.Debug().Msg("Transfer complete")
 }
+
+// HttpProxyConnectAuthHandler returns a CONNECT handler, that requires basic authentication with `Proxy-Authorization`.
+// Without valid credentials it responds with 407 and `Proxy-Authenticate`.
+func HttpProxyConnectAuthHandler(ctx context.Context, username, password string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !proxyAuthorized(r, username, password) {
+			w.Header().Set("Proxy-Authenticate", `Basic realm="proxy"`)
+			http.Error(w, http.StatusText(http.StatusProxyAuthRequired), http.StatusProxyAuthRequired)
+			return
+		}
+		serveConnect(ctx, w, r)
+	}
+}
+
+// proxyAuthorized checks the basic credentials of the `Proxy-Authorization` header
+func proxyAuthorized(r *http.Request, username, password string) bool {
+	// http.Request.BasicAuth reads only the Authorization header
+	req := &http.Request{Header: http.Header{"Authorization": r.Header.Values("Proxy-Authorization")}}
+	u, p, ok := req.BasicAuth()
+	return ok &&
+		subtle.ConstantTimeCompare([]byte(u), []byte(username)) == 1 &&
+		subtle.ConstantTimeCompare([]byte(p), []byte(password)) == 1
+}
+
+// forwardProxy forwards plain HTTP proxy requests to the absolute URI of the request.
+// Hop-by-hop headers, including `Proxy-Authorization`, are not forwarded.
+var forwardProxy = &httputil.ReverseProxy{
+	Rewrite: func(*httputil.ProxyRequest) {},
+	// the environment proxy settings must not apply to the test proxy, and bodies are passed as is
+	Transport: &http.Transport{DisableCompression: true},
+}
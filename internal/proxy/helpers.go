@@ -1,20 +1,64 @@
 package proxy
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/rs/zerolog/log"
 )
 
+// drainTimeout is the time given to a tunnel to write the data it has already read, after its context is done
+const drainTimeout = 5 * time.Second
+
+// tunnel copies data between the connections in both directions until both directions are finished.
+// When the context is done, reads from both connections are stopped by the deadline,
+// and the connections are closed as soon as pending writes are finished, or after drainTimeout.
+func tunnel(ctx context.Context, clientConn, targetConn net.Conn) {
+	wg := &sync.WaitGroup{}
+	wg.Add(2)
+	go copyIO(wg, targetConn, clientConn)
+	go copyIO(wg, clientConn, targetConn)
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return
+	case <-ctx.Done():
+	}
+
+	now := time.Now()
+	clientConn.SetReadDeadline(now)
+	targetConn.SetReadDeadline(now)
+
+	timer := time.NewTimer(drainTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+	}
+	clientConn.Close()
+	targetConn.Close()
+	<-done
+}
+
 func copyIO(wg *sync.WaitGroup, dst, src net.Conn) {
 	defer wg.Done()
 	if _, err := io.Copy(dst, src); err != nil {
-		if !strings.Contains(err.Error(), "use of closed network connection") {
+		if !errors.Is(err, net.ErrClosed) && !errors.Is(err, os.ErrDeadlineExceeded) &&
+			!strings.Contains(err.Error(), "use of closed network connection") {
 			log.Error().Err(err).Msg("")
 		}
 		return
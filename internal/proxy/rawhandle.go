@@ -2,15 +2,22 @@ package proxy
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"strings"
-	"sync"
 
 	"github.com/rs/zerolog/log"
 )
 
+// defaultProto is used in the status line if the request line can't be read
+const defaultProto = "HTTP/1.1"
+
+var errMalformedRequestLine = errors.New("malformed request line")
+
 type requestLine struct {
 	method    string
 	authority string
@@ -18,29 +25,38 @@ type requestLine struct {
 }
 
 func readRequestLine(scanner *bufio.Scanner) (rLine *requestLine, err error) {
-
-	for scanner.Scan() {
-		line := scanner.Text()
-		parts := strings.Split(line, " ")
-		rLine = &requestLine{
-			method:    parts[0],
-			authority: parts[1],
-			proto:     parts[2],
+	if !scanner.Scan() {
+		if err = scanner.Err(); err == nil {
+			err = io.ErrUnexpectedEOF
 		}
-		break
+		return
+	}
+	parts := strings.Split(scanner.Text(), " ")
+	if len(parts) != 3 {
+		err = fmt.Errorf("%w: %q", errMalformedRequestLine, scanner.Text())
+		return
+	}
+	rLine = &requestLine{
+		method:    parts[0],
+		authority: parts[1],
+		proto:     parts[2],
 	}
-	err = scanner.Err()
 	return
 }
 
 func ProxyConnectHandle(clientConn net.Conn) {
+	ProxyConnectHandleContext(context.Background(), clientConn)
+}
+
+// ProxyConnectHandleContext handles a CONNECT request on the connection, the tunnel is shut down when the context is done
+func ProxyConnectHandleContext(ctx context.Context, clientConn net.Conn) {
 
 	scanner := bufio.NewScanner(clientConn)
 
 	r, err := readRequestLine(scanner)
 
 	if err != nil {
-		writeStatusLine(clientConn, http.StatusInternalServerError, r.proto)
+		writeStatusLine(clientConn, http.StatusBadRequest, defaultProto)
 		clientConn.Close()
 		return
 	}
@@ -56,7 +72,7 @@ func ProxyConnectHandle(clientConn net.Conn) {
 		Str("to", r.authority).
 		Logger()
 
-	targetConn, err := net.Dial("tcp", r.authority)
+	targetConn, err := (&net.Dialer{}).DialContext(ctx, "tcp", r.authority)
 	if err != nil {
 		writeStatusLine(clientConn, http.StatusInternalServerError, r.proto)
 		clientConn.Close()
@@ -67,11 +83,7 @@ func ProxyConnectHandle(clientConn net.Conn) {
 	writeStatusLine(clientConn, http.StatusOK, r.proto)
 
 	logger.Debug().Msg("Transfer start")
-	wg := &sync.WaitGroup{}
-	wg.Add(2)
-	go copyIO(wg, targetConn, clientConn)
-	go copyIO(wg, clientConn, targetConn)
-	wg.Wait()
+	tunnel(ctx, clientConn, targetConn)
 
 	fmt.Printf("Transfer complete\n")
 }
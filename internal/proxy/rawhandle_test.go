@@ -0,0 +1,40 @@
+package proxy
+
+import (
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyConnectHandle_BadRequestLine(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go ProxyConnectHandle(conn)
+		}
+	}()
+
+	for _, line := range []string{"", "GARBAGE\r\n", "CONNECT example.com:443\r\n"} {
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		require.NoError(t, err)
+
+		_, err = conn.Write([]byte(line))
+		require.NoError(t, err)
+		require.NoError(t, conn.(*net.TCPConn).CloseWrite())
+
+		resp, err := io.ReadAll(conn)
+		conn.Close()
+		assert.NoError(t, err)
+		assert.Equal(t, "HTTP/1.1 400 Bad Request\r\n\r\n", string(resp), "%q", line)
+	}
+}
@@ -0,0 +1,42 @@
+package jar_test
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/niklak/apik"
+	"github.com/niklak/apik/jar"
+	"github.com/niklak/apik/request"
+	"github.com/niklak/httpbulb"
+)
+
+func TestJar_Client(t *testing.T) {
+	testServer := httptest.NewServer(httpbulb.NewRouter())
+	defer testServer.Close()
+
+	j := jar.New()
+	client := apik.New(apik.WithBaseUrl(testServer.URL), apik.WithCookieJar(j))
+
+	_, err := client.Fetch(request.NewRequest(context.Background(), "/cookies/set?k1=v1&k2=v2"), nil)
+	require.NoError(t, err)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, j.ExportNetscape(buf))
+
+	// the exported cookies are sent by another client
+	imported := jar.New()
+	require.NoError(t, imported.ImportNetscape(buf))
+	client = apik.New(apik.WithBaseUrl(testServer.URL), apik.WithCookieJar(imported))
+
+	var result struct {
+		Cookies map[string][]string `json:"cookies"`
+	}
+	_, err = client.JSON(request.NewRequest(context.Background(), "/cookies"), &result)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{"k1": {"v1"}, "k2": {"v2"}}, result.Cookies)
+}
@@ -0,0 +1,114 @@
+package jar
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrMalformedLine is returned by ImportNetscape for lines, that are not comments and don't have 7 fields
+var ErrMalformedLine = errors.New("malformed cookies.txt line")
+
+const (
+	netscapeHeader   = "# Netscape HTTP Cookie File\n"
+	httpOnlyPrefix   = "#HttpOnly_"
+	netscapeTrue     = "TRUE"
+	netscapeFalse    = "FALSE"
+	netscapeFieldSep = "\t"
+)
+
+// ExportNetscape writes the cookies in the Netscape cookies.txt format of curl and browser extensions:
+// one line per cookie with tab-separated domain, subdomains flag, path, secure flag, expiration, name and value.
+// Domains of HttpOnly cookies have the `#HttpOnly_` prefix, domain cookies have a leading dot.
+func (j *Jar) ExportNetscape(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(netscapeHeader)
+	for _, c := range j.All() {
+		domain := c.Domain
+		if !c.HostOnly {
+			domain = "." + domain
+		}
+		if c.HttpOnly {
+			domain = httpOnlyPrefix + domain
+		}
+		fields := []string{
+			domain, netscapeBool(!c.HostOnly), c.Path, netscapeBool(c.Secure),
+			strconv.FormatInt(c.Expires, 10), c.Name, c.Value,
+		}
+		bw.WriteString(strings.Join(fields, netscapeFieldSep))
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// ImportNetscape reads cookies in the Netscape cookies.txt format and stores them, see ExportNetscape.
+// Comments and empty lines are skipped, as well as expired cookies. A line without the value is a cookie with an empty value.
+// If a line is malformed, ErrMalformedLine is returned and no cookies are stored.
+func (j *Jar) ImportNetscape(r io.Reader) error {
+	var cookies []Cookie
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		httpOnly := strings.HasPrefix(line, httpOnlyPrefix)
+		line = strings.TrimPrefix(line, httpOnlyPrefix)
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, netscapeFieldSep)
+		if len(fields) == 6 {
+			fields = append(fields, "")
+		}
+		if len(fields) != 7 {
+			return fmt.Errorf("%w %d: %d fields", ErrMalformedLine, n, len(fields))
+		}
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w %d: %w", ErrMalformedLine, n, err)
+		}
+		domain := strings.TrimPrefix(fields[0], ".")
+		cookies = append(cookies, Cookie{
+			Name:     fields[5],
+			Value:    fields[6],
+			Domain:   strings.ToLower(domain),
+			HostOnly: !strings.EqualFold(fields[1], netscapeTrue),
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], netscapeTrue),
+			HttpOnly: httpOnly,
+			Expires:  expires,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	j.Add(cookies...)
+	return nil
+}
+
+// ExportJSON writes the cookies as a JSON array of Cookie objects
+func (j *Jar) ExportJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(j.All())
+}
+
+// ImportJSON reads a JSON array of Cookie objects and stores the cookies, expired cookies are skipped
+func (j *Jar) ImportJSON(r io.Reader) error {
+	var cookies []Cookie
+	if err := json.NewDecoder(r).Decode(&cookies); err != nil {
+		return err
+	}
+	j.Add(cookies...)
+	return nil
+}
+
+func netscapeBool(v bool) string {
+	if v {
+		return netscapeTrue
+	}
+	return netscapeFalse
+}
@@ -0,0 +1,188 @@
+// Package jar provides a cookie jar, that can export its cookies and import them,
+// in the Netscape cookies.txt format of curl and browser extensions, or in JSON.
+package jar
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Cookie is a cookie stored in the Jar, with the attributes needed to restore it
+type Cookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	// Domain is the domain of the cookie, without the leading dot
+	Domain string `json:"domain"`
+	// HostOnly reports whether the cookie is sent only to the Domain, not to its subdomains
+	HostOnly bool   `json:"hostOnly"`
+	Path     string `json:"path"`
+	Secure   bool   `json:"secure"`
+	HttpOnly bool   `json:"httpOnly"`
+	// Expires is the expiration time in unix seconds, 0 for a session cookie
+	Expires int64 `json:"expires"`
+}
+
+// expired reports whether the cookie is expired at the time
+func (c *Cookie) expired(now time.Time) bool {
+	return c.Expires > 0 && c.Expires <= now.Unix()
+}
+
+// url returns the URL, which the cookie is set from and sent to
+func (c *Cookie) url() *url.URL {
+	scheme := "http"
+	if c.Secure {
+		scheme = "https"
+	}
+	return &url.URL{Scheme: scheme, Host: c.Domain, Path: c.Path}
+}
+
+// httpCookie returns the cookie as it is set with Set-Cookie
+func (c *Cookie) httpCookie() *http.Cookie {
+	cookie := &http.Cookie{Name: c.Name, Value: c.Value, Path: c.Path, Secure: c.Secure, HttpOnly: c.HttpOnly}
+	if !c.HostOnly {
+		cookie.Domain = c.Domain
+	}
+	if c.Expires > 0 {
+		cookie.Expires = time.Unix(c.Expires, 0)
+	}
+	return cookie
+}
+
+type cookieKey struct {
+	domain, path, name string
+}
+
+// Jar is an http.CookieJar, that keeps all attributes of the stored cookies, so they can be exported.
+// Cookies are matched and sent by net/http/cookiejar with the public suffix list. It is safe for concurrent use.
+type Jar struct {
+	mu      sync.Mutex
+	inner   *cookiejar.Jar
+	cookies map[cookieKey]*Cookie
+}
+
+// New returns an empty Jar
+func New() *Jar {
+	inner, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	return &Jar{inner: inner, cookies: make(map[cookieKey]*Cookie)}
+}
+
+// SetCookies stores the cookies received from the URL
+func (j *Jar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.inner.SetCookies(u, cookies)
+
+	now := time.Now()
+	for _, cookie := range cookies {
+		c := newCookie(u, cookie, now)
+		key := cookieKey{c.Domain, c.Path, c.Name}
+		// the cookie is kept only if the jar accepted it, e.g. it's not deleted or set for a public suffix
+		if j.accepted(c) {
+			j.cookies[key] = c
+		} else {
+			delete(j.cookies, key)
+		}
+	}
+}
+
+// Cookies returns the cookies to send to the URL
+func (j *Jar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.Lock()
+	inner := j.inner
+	j.mu.Unlock()
+	return inner.Cookies(u)
+}
+
+// All returns all stored cookies, that are not expired, sorted by domain, path and name
+func (j *Jar) All() []Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	all := make([]Cookie, 0, len(j.cookies))
+	for _, c := range j.cookies {
+		if !c.expired(now) {
+			all = append(all, *c)
+		}
+	}
+	slices.SortFunc(all, func(a, b Cookie) int {
+		return strings.Compare(a.Domain+"\x00"+a.Path+"\x00"+a.Name, b.Domain+"\x00"+b.Path+"\x00"+b.Name)
+	})
+	return all
+}
+
+// Clear removes all cookies
+func (j *Jar) Clear() {
+	inner, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.inner = inner
+	j.cookies = make(map[cookieKey]*Cookie)
+}
+
+// Add stores the cookies as if they were received from their domains. Expired cookies are skipped.
+func (j *Jar) Add(cookies ...Cookie) {
+	now := time.Now()
+	for _, c := range cookies {
+		if c.expired(now) {
+			continue
+		}
+		j.SetCookies(c.url(), []*http.Cookie{c.httpCookie()})
+	}
+}
+
+// accepted reports whether the cookie is sent back by the inner jar
+func (j *Jar) accepted(c *Cookie) bool {
+	for _, cookie := range j.inner.Cookies(c.url()) {
+		if cookie.Name == c.Name && cookie.Value == c.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// newCookie returns the cookie received from the URL with the attributes applied like in net/http/cookiejar
+func newCookie(u *url.URL, cookie *http.Cookie, now time.Time) *Cookie {
+	c := &Cookie{
+		Name:     cookie.Name,
+		Value:    cookie.Value,
+		Domain:   strings.ToLower(strings.TrimPrefix(cookie.Domain, ".")),
+		Path:     cookie.Path,
+		Secure:   cookie.Secure,
+		HttpOnly: cookie.HttpOnly,
+	}
+	if c.Domain == "" {
+		c.Domain, c.HostOnly = strings.ToLower(u.Hostname()), true
+	}
+	if !strings.HasPrefix(c.Path, "/") {
+		c.Path = defaultPath(u.Path)
+	}
+	switch {
+	case cookie.MaxAge > 0:
+		c.Expires = now.Add(time.Duration(cookie.MaxAge) * time.Second).Unix()
+	case !cookie.Expires.IsZero():
+		c.Expires = cookie.Expires.Unix()
+	}
+	return c
+}
+
+// defaultPath returns the directory of the URL path, see RFC 6265 section 5.1.4
+func defaultPath(path string) string {
+	if path == "" || path[0] != '/' {
+		return "/"
+	}
+	i := strings.LastIndex(path, "/")
+	if i == 0 {
+		return "/"
+	}
+	return path[:i]
+}
@@ -0,0 +1,102 @@
+package jar
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const cookiesTxt = `# Netscape HTTP Cookie File
+# https://curl.se/docs/http-cookies.html
+
+.example.com	TRUE	/	TRUE	4102444800	session	abc123
+#HttpOnly_www.example.com	FALSE	/app	FALSE	0	token	t0k3n
+www.example.com	FALSE	/	FALSE	0	empty
+.example.com	TRUE	/	FALSE	1000000000	expired	old
+`
+
+func mustParse(t *testing.T, rawURL string) *url.URL {
+	u, err := url.Parse(rawURL)
+	require.NoError(t, err)
+	return u
+}
+
+func cookieNames(cookies []*http.Cookie) []string {
+	names := make([]string, 0, len(cookies))
+	for _, c := range cookies {
+		names = append(names, c.Name+"="+c.Value)
+	}
+	return names
+}
+
+func TestJar_ImportNetscape(t *testing.T) {
+	j := New()
+	require.NoError(t, j.ImportNetscape(strings.NewReader(cookiesTxt)))
+
+	assert.Equal(t, []Cookie{
+		{Name: "session", Value: "abc123", Domain: "example.com", Path: "/", Secure: true, Expires: 4102444800},
+		{Name: "empty", Value: "", Domain: "www.example.com", HostOnly: true, Path: "/"},
+		{Name: "token", Value: "t0k3n", Domain: "www.example.com", HostOnly: true, Path: "/app", HttpOnly: true},
+	}, j.All())
+
+	assert.ElementsMatch(t, []string{"session=abc123", "token=t0k3n", "empty="},
+		cookieNames(j.Cookies(mustParse(t, "https://www.example.com/app/page"))))
+	// the secure cookie is not sent over http, host-only cookies are not sent to subdomains
+	assert.Empty(t, j.Cookies(mustParse(t, "http://api.example.com/")))
+	assert.Equal(t, []string{"session=abc123"}, cookieNames(j.Cookies(mustParse(t, "https://api.example.com/"))))
+}
+
+func TestJar_ImportNetscapeMalformed(t *testing.T) {
+	j := New()
+	err := j.ImportNetscape(strings.NewReader("example.com\tFALSE\t/\tFALSE\t0\tname\tvalue\nexample.com\tFALSE\t/\n"))
+	assert.ErrorIs(t, err, ErrMalformedLine)
+	assert.ErrorContains(t, err, "line 2")
+	assert.Empty(t, j.All())
+
+	err = j.ImportNetscape(strings.NewReader("example.com\tFALSE\t/\tFALSE\tnever\tname\tvalue\n"))
+	assert.ErrorIs(t, err, ErrMalformedLine)
+}
+
+func TestJar_RoundTrip(t *testing.T) {
+	j := New()
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	j.SetCookies(mustParse(t, "https://shop.example.org/cart/items"), []*http.Cookie{
+		{Name: "cart", Value: "42"},
+		{Name: "user", Value: "alice", Domain: ".example.org", Path: "/", Secure: true, HttpOnly: true, Expires: expires},
+		{Name: "prefs", Value: "dark", MaxAge: 60},
+		// the domain is a public suffix, so the cookie is rejected
+		{Name: "tracker", Value: "1", Domain: "org"},
+	})
+	all := j.All()
+	require.Len(t, all, 3)
+	assert.Equal(t, Cookie{Name: "cart", Value: "42", Domain: "shop.example.org", HostOnly: true, Path: "/cart"}, all[1])
+	assert.Equal(t, expires.Unix(), all[0].Expires)
+
+	netscape := new(bytes.Buffer)
+	require.NoError(t, j.ExportNetscape(netscape))
+	assert.Contains(t, netscape.String(), "#HttpOnly_.example.org\tTRUE\t/\tTRUE\t")
+	assert.Contains(t, netscape.String(), "shop.example.org\tFALSE\t/cart\tFALSE\t0\tcart\t42\n")
+
+	fromNetscape := New()
+	require.NoError(t, fromNetscape.ImportNetscape(netscape))
+	assert.Equal(t, all, fromNetscape.All())
+
+	js := new(bytes.Buffer)
+	require.NoError(t, j.ExportJSON(js))
+	fromJSON := New()
+	require.NoError(t, fromJSON.ImportJSON(js))
+	assert.Equal(t, all, fromJSON.All())
+
+	u := mustParse(t, "https://shop.example.org/cart")
+	assert.ElementsMatch(t, cookieNames(j.Cookies(u)), cookieNames(fromJSON.Cookies(u)))
+
+	// a deleted cookie is not exported
+	j.SetCookies(mustParse(t, "https://shop.example.org/cart/items"), []*http.Cookie{{Name: "cart", MaxAge: -1}})
+	assert.Len(t, j.All(), 2)
+}
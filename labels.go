@@ -0,0 +1,17 @@
+package apik
+
+import "context"
+
+// labelsKey is the context key of the request labels, see reqopt.Label
+type labelsKey struct{}
+
+// withLabels returns a copy of the context with the request labels
+func withLabels(ctx context.Context, labels map[string]string) context.Context {
+	return context.WithValue(ctx, labelsKey{}, labels)
+}
+
+// requestLabels returns the request labels from the context, nil if there are none
+func requestLabels(ctx context.Context) map[string]string {
+	labels, _ := ctx.Value(labelsKey{}).(map[string]string)
+	return labels
+}
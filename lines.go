@@ -0,0 +1,48 @@
+package apik
+
+import (
+	"bufio"
+	"net/http"
+)
+
+// defaultMaxLineLength is the default maximum length of a line read by FetchLines
+const defaultMaxLineLength = 1 << 20
+
+// WithMaxLineLength sets the maximum length of a line read by FetchLines. Default is 1MB.
+// Longer lines stop reading with bufio.ErrTooLong.
+func WithMaxLineLength(n int) ClientOption {
+	return func(c *Client) {
+		c.maxLineLength = n
+	}
+}
+
+// FetchLines sends an http.Request built from Request and passes every line of the response body,
+// without the trailing "\n" or "\r\n", to the handler, until the body ends, the handler returns an error,
+// or the request context is cancelled. It's simpler than SSE for plain-text streams, e.g. tailed logs.
+// The line is valid only until the handler returns.
+func (c *Client) FetchLines(req *Request, handler func(line []byte) error) (resp *Response, err error) {
+	var rawResp *http.Response
+	var attempts int
+	if rawResp, attempts, err = c.doStream(req); err != nil {
+		return
+	}
+	defer rawResp.Body.Close()
+	resp = c.newResponse(rawResp, attempts)
+
+	maxLineLength := c.maxLineLength
+	if maxLineLength <= 0 {
+		maxLineLength = defaultMaxLineLength
+	}
+	scanner := bufio.NewScanner(resp.bodyReader(req.Ctx, rawResp.Body))
+	scanner.Buffer(make([]byte, 0, min(maxLineLength, bufio.MaxScanTokenSize)), maxLineLength)
+	for scanner.Scan() {
+		if err = handler(scanner.Bytes()); err != nil {
+			return
+		}
+	}
+	err = scanner.Err()
+	if ctxErr := req.Ctx.Err(); err != nil && ctxErr != nil {
+		err = ctxErr
+	}
+	return
+}
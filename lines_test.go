@@ -0,0 +1,90 @@
+package apik
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/niklak/apik/request"
+)
+
+func TestClient_FetchLines(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/long" {
+			fmt.Fprintf(w, "short\n%s\n", strings.Repeat("x", 100))
+			return
+		}
+		fmt.Fprint(w, "first\r\n\nsecond\nlast")
+	}))
+	defer server.Close()
+
+	client := New(WithBaseUrl(server.URL), WithMaxLineLength(64))
+
+	var lines []string
+	resp, err := client.FetchLines(request.NewRequest(context.Background(), "/logs"), func(line []byte) error {
+		lines = append(lines, string(line))
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, []string{"first", "", "second", "last"}, lines)
+
+	// handler error stops reading
+	errStop := errors.New("stop")
+	count := 0
+	_, err = client.FetchLines(request.NewRequest(context.Background(), "/logs"), func(line []byte) error {
+		count++
+		return errStop
+	})
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, 1, count)
+
+	lines = nil
+	_, err = client.FetchLines(request.NewRequest(context.Background(), "/long"), func(line []byte) error {
+		lines = append(lines, string(line))
+		return nil
+	})
+	assert.ErrorIs(t, err, bufio.ErrTooLong)
+	assert.Equal(t, []string{"short"}, lines)
+}
+
+func TestClient_FetchLinesCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for {
+			if _, err := fmt.Fprint(w, "tick\n"); err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}))
+	defer server.Close()
+
+	client := New(WithBaseUrl(server.URL))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	count := 0
+	_, err := client.FetchLines(request.NewRequest(ctx, "/tail"), func(line []byte) error {
+		count++
+		if count == 3 {
+			cancel()
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.GreaterOrEqual(t, count, 3)
+}
@@ -0,0 +1,101 @@
+package apik
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	defaultListItemsField = "items"
+	defaultListTotalField = "total"
+)
+
+// listEnvelope represents field names of a paginated list response
+type listEnvelope struct {
+	items string
+	total string
+}
+
+// WithListEnvelope sets the field names of the paginated list envelope for JSONList.
+// Nested fields are separated by dots, e.g. `data` and `meta.total`. Default is `items` and `total`.
+func WithListEnvelope(itemsField, totalField string) ClientOption {
+	return func(c *Client) {
+		c.listEnvelope = listEnvelope{items: itemsField, total: totalField}
+	}
+}
+
+// JSONList sends an http.Request built from Request and decodes a paginated list envelope,
+// such as `{"items": [...], "total": N}`: the items are decoded into the slice pointed by items,
+// and the total count is returned. If the envelope has no total field, the total is the number of items.
+// Field names are set with WithListEnvelope. A missing items field results in ErrMissingField.
+func (c *Client) JSONList(req *Request, items any) (total int, resp *Response, err error) {
+	if err = checkResult(items); err != nil {
+		return
+	}
+	var envelope map[string]json.RawMessage
+	if resp, err = c.JSON(req, &envelope); err != nil || envelope == nil {
+		return
+	}
+
+	fields := c.listEnvelope
+	if fields.items == "" {
+		fields.items = defaultListItemsField
+	}
+	if fields.total == "" {
+		fields.total = defaultListTotalField
+	}
+
+	rawItems, err := envelopeField(envelope, fields.items)
+	if err != nil {
+		return
+	}
+	if rawItems == nil {
+		err = fmt.Errorf("%w: %s", ErrMissingField, fields.items)
+		return
+	}
+	if err = c.unmarshalJSON(rawItems, items); err != nil {
+		return
+	}
+	resp.Result = items
+
+	rawTotal, err := envelopeField(envelope, fields.total)
+	if err != nil {
+		return
+	}
+	if rawTotal == nil {
+		var list []json.RawMessage
+		if err = json.Unmarshal(rawItems, &list); err != nil {
+			return
+		}
+		total = len(list)
+		return
+	}
+	err = json.Unmarshal(rawTotal, &total)
+	return
+}
+
+// envelopeField returns the raw value of the dot-separated field, or nil if it is missing
+func envelopeField(envelope map[string]json.RawMessage, field string) (json.RawMessage, error) {
+	path := strings.Split(field, ".")
+	obj := envelope
+	for _, key := range path[:len(path)-1] {
+		raw, ok := obj[key]
+		if !ok {
+			return nil, nil
+		}
+		obj = nil
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return nil, err
+		}
+	}
+	return obj[path[len(path)-1]], nil
+}
+
+// unmarshalJSON decodes JSON data with the unmarshaler of the client
+func (c *Client) unmarshalJSON(data []byte, v any) error {
+	if c.jsonUnmarshaler == nil {
+		return json.Unmarshal(data, v)
+	}
+	return c.jsonUnmarshaler.Unmarshal(data, v)
+}
@@ -0,0 +1,69 @@
+package apik
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/niklak/apik/request"
+)
+
+func TestClient_JSONList(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/items":
+			w.Write([]byte(`{"items":[{"name":"alice"},{"name":"bob"}],"total":42}`))
+		case "/nested":
+			w.Write([]byte(`{"data":[{"name":"alice"}],"meta":{"page":1,"total":7}}`))
+		case "/no-total":
+			w.Write([]byte(`{"items":[{"name":"alice"},{"name":"bob"}]}`))
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Write([]byte(`{"results":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	type user struct {
+		Name string `json:"name"`
+	}
+
+	client := New(WithBaseUrl(server.URL))
+
+	var users []user
+	total, resp, err := client.JSONList(request.NewRequest(context.Background(), "/items"), &users)
+	require.NoError(t, err)
+	assert.Equal(t, 42, total)
+	assert.Equal(t, []user{{"alice"}, {"bob"}}, users)
+	assert.Equal(t, &users, resp.Result)
+
+	users = nil
+	total, _, err = client.JSONList(request.NewRequest(context.Background(), "/no-total"), &users)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+
+	users = nil
+	total, _, err = client.JSONList(request.NewRequest(context.Background(), "/empty"), &users)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	assert.Nil(t, users)
+
+	_, _, err = client.JSONList(request.NewRequest(context.Background(), "/other"), &users)
+	assert.ErrorIs(t, err, ErrMissingField)
+
+	_, _, err = client.JSONList(request.NewRequest(context.Background(), "/items"), users)
+	assert.ErrorIs(t, err, ErrResultNotPointer)
+
+	client = New(WithBaseUrl(server.URL), WithListEnvelope("data", "meta.total"), WithJSONUnmarshaler(&countingJSON{}))
+	users = nil
+	total, _, err = client.JSONList(request.NewRequest(context.Background(), "/nested"), &users)
+	require.NoError(t, err)
+	assert.Equal(t, 7, total)
+	assert.Equal(t, []user{{"alice"}}, users)
+}
@@ -0,0 +1,152 @@
+package apik
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// maxLoggedBodySize is the maximum size of the request body that is logged
+const maxLoggedBodySize = 4 << 10
+
+// redactedValue replaces sensitive values in logs
+const redactedValue = "[REDACTED]"
+
+// sensitiveHeaders are masked by DefaultRedactor
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// DefaultRedactor returns a redactor for WithRedactor, that masks `Authorization`, `Proxy-Authorization`,
+// `Cookie` and `Set-Cookie` headers and the given fields of JSON and form bodies. Field names are case-insensitive,
+// JSON fields are masked at any depth. A body that can't be parsed is returned as is.
+func DefaultRedactor(fields ...string) func(header http.Header, body []byte) []byte {
+	return func(header http.Header, body []byte) []byte {
+		for _, key := range sensitiveHeaders {
+			if _, ok := header[key]; ok {
+				header.Set(key, redactedValue)
+			}
+		}
+		if len(body) == 0 || len(fields) == 0 {
+			return body
+		}
+		contentType := header.Get("Content-Type")
+		switch {
+		case strings.Contains(contentType, "json"):
+			return redactJSON(body, fields)
+		case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+			return redactForm(body, fields)
+		}
+		return body
+	}
+}
+
+func redactJSON(body []byte, fields []string) []byte {
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return body
+	}
+	if !redactValue(v, fields) {
+		return body
+	}
+	redacted, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+// redactValue masks the fields in JSON objects at any depth and reports whether something was masked
+func redactValue(v any, fields []string) (redacted bool) {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if containsFold(fields, key) {
+				v[key] = redactedValue
+				redacted = true
+				continue
+			}
+			redacted = redactValue(value, fields) || redacted
+		}
+	case []any:
+		for _, value := range v {
+			redacted = redactValue(value, fields) || redacted
+		}
+	}
+	return
+}
+
+func redactForm(body []byte, fields []string) []byte {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return body
+	}
+	redacted := false
+	for key := range values {
+		if containsFold(fields, key) {
+			values.Set(key, redactedValue)
+			redacted = true
+		}
+	}
+	if !redacted {
+		return body
+	}
+	return []byte(values.Encode())
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// logRequest logs the http.Request at debug level. Headers and the body are passed through the redactor.
+// The body is logged only if it can be obtained again and is not too large.
+func (c *Client) logRequest(rawReq *http.Request) {
+	header := rawReq.Header.Clone()
+	var body []byte
+	if rawReq.GetBody != nil && rawReq.ContentLength > 0 && rawReq.ContentLength <= maxLoggedBodySize {
+		if rc, err := rawReq.GetBody(); err == nil {
+			body, _ = io.ReadAll(rc)
+			rc.Close()
+		}
+	}
+	body = c.redactor(header, body)
+
+	event := c.requestLogger.Debug().
+		Str("method", rawReq.Method).
+		Str("url", rawReq.URL.Redacted())
+	logLabels(event, rawReq).
+		Interface("header", header).
+		Str("body", string(body)).
+		Msg("request")
+}
+
+// logResponse logs the result of the request at debug level. Headers are passed through the redactor.
+func (c *Client) logResponse(rawReq *http.Request, resp *http.Response, err error, elapsed time.Duration) {
+	event := c.requestLogger.Debug().
+		Str("method", rawReq.Method).
+		Str("url", rawReq.URL.Redacted())
+	logLabels(event, rawReq).Dur("elapsed", elapsed)
+	if err != nil {
+		event.Err(err).Msg("response")
+		return
+	}
+	header := resp.Header.Clone()
+	c.redactor(header, nil)
+	event.Int("status", resp.StatusCode).Interface("header", header).Msg("response")
+}
+
+// logLabels adds the request labels to the log event, if there are any
+func logLabels(event *zerolog.Event, rawReq *http.Request) *zerolog.Event {
+	if labels := requestLabels(rawReq.Context()); len(labels) > 0 {
+		event.Interface("labels", labels)
+	}
+	return event
+}
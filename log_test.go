@@ -0,0 +1,161 @@
+package apik
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/niklak/apik/reqopt"
+	"github.com/niklak/apik/request"
+	"github.com/niklak/httpbulb"
+)
+
+func TestClient_Logger(t *testing.T) {
+
+	testServer := httptest.NewServer(httpbulb.NewRouter())
+	defer testServer.Close()
+
+	buf := new(bytes.Buffer)
+	client := New(
+		WithBaseUrl(testServer.URL),
+		WithLogger(zerolog.New(buf)),
+		WithHeader("Authorization", "Bearer secret-token"),
+	)
+
+	_, err := client.Fetch(
+		request.NewRequest(
+			context.Background(),
+			"/post",
+			reqopt.Method(http.MethodPost),
+			reqopt.SetJSON(map[string]any{"user": "admin", "password": "secret-password"}),
+		),
+		nil,
+	)
+	assert.NoError(t, err)
+
+	_, err = client.Fetch(
+		request.NewRequest(
+			context.Background(),
+			"/response-headers",
+			reqopt.SetParam("Set-Cookie", "session=secret-session"),
+		),
+		nil,
+	)
+	assert.NoError(t, err)
+
+	logs := buf.String()
+	assert.Equal(t, 4, strings.Count(logs, "\n"))
+	assert.Contains(t, logs, `"message":"request"`)
+	assert.Contains(t, logs, `"status":200`)
+	assert.Contains(t, logs, `admin`)
+	assert.Contains(t, logs, redactedValue)
+	assert.Contains(t, logs, `"Set-Cookie":["[REDACTED]"]`)
+	assert.NotContains(t, logs, "secret-token")
+	assert.NotContains(t, logs, "secret-password")
+	assert.Equal(t, 2, strings.Count(logs, "secret-session"), "only in the URL of the request and the response")
+}
+
+func TestDefaultRedactor(t *testing.T) {
+
+	redactor := DefaultRedactor("password", "token")
+
+	header := http.Header{}
+	header.Set("Authorization", "Basic dXNlcjpwYXNz")
+	header.Set("Content-Type", "application/json")
+	body := redactor(header, []byte(`{"user":"u","Password":"p","nested":[{"token":"t","id":1}]}`))
+	assert.Equal(t, redactedValue, header.Get("Authorization"))
+	assert.JSONEq(t, `{"user":"u","Password":"[REDACTED]","nested":[{"token":"[REDACTED]","id":1}]}`, string(body))
+
+	header = http.Header{"Content-Type": {"application/x-www-form-urlencoded"}}
+	body = redactor(header, []byte("user=u&password=p"))
+	assert.Equal(t, "password=%5BREDACTED%5D&user=u", string(body))
+
+	// bodies without sensitive fields are kept as is
+	header = http.Header{"Content-Type": {"application/json"}}
+	body = redactor(header, []byte(`{"b":1, "a":2}`))
+	assert.Equal(t, `{"b":1, "a":2}`, string(body))
+}
+
+func TestClient_SlowRequestThreshold(t *testing.T) {
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(50 * time.Millisecond)
+		}
+	}))
+	defer testServer.Close()
+
+	buf := new(bytes.Buffer)
+	client := New(
+		WithBaseUrl(testServer.URL),
+		WithLogger(zerolog.New(buf).Level(zerolog.InfoLevel)),
+		WithSlowRequestThreshold(20*time.Millisecond),
+	)
+
+	_, err := client.Fetch(request.NewRequest(context.Background(), "/fast"), nil)
+	assert.NoError(t, err)
+	assert.Empty(t, buf.String())
+
+	_, err = client.Fetch(request.NewRequest(context.Background(), "/slow?token=x"), nil)
+	assert.NoError(t, err)
+
+	logs := buf.String()
+	assert.Contains(t, logs, `"level":"warn"`)
+	assert.Contains(t, logs, `"message":"slow request"`)
+	assert.Contains(t, logs, `"method":"GET"`)
+	assert.Contains(t, logs, `"url":"`+testServer.URL+`/slow?token=x"`)
+	assert.Contains(t, logs, `"attempts":1`)
+	assert.NotContains(t, logs, `"first_byte"`)
+
+	// the level and the message are configurable, traced requests have the timing breakdown
+	buf.Reset()
+	client = New(
+		WithBaseUrl(testServer.URL),
+		WithLogger(zerolog.New(buf).Level(zerolog.InfoLevel)),
+		WithSlowRequestThreshold(20*time.Millisecond, SlowRequestLevel(zerolog.ErrorLevel), SlowRequestMessage("dependency is slow")),
+		WithTrace(),
+	)
+	_, err = client.Fetch(request.NewRequest(context.Background(), "/slow"), nil)
+	assert.NoError(t, err)
+
+	logs = buf.String()
+	assert.Contains(t, logs, `"level":"error"`)
+	assert.Contains(t, logs, `"message":"dependency is slow"`)
+	assert.Contains(t, logs, `"connect":`)
+	assert.Contains(t, logs, `"first_byte":`)
+}
+
+func TestClient_LoggerLabels(t *testing.T) {
+
+	testServer := httptest.NewServer(httpbulb.NewRouter())
+	defer testServer.Close()
+
+	buf := new(bytes.Buffer)
+	client := New(WithBaseUrl(testServer.URL), WithLogger(zerolog.New(buf)))
+
+	req := request.NewRequest(context.Background(), "/get", reqopt.Label("operation", "getThing"))
+	resp, err := client.Fetch(req, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"operation": "getThing"}, resp.Labels)
+
+	logs := buf.String()
+	assert.Equal(t, 2, strings.Count(logs, `"labels":{"operation":"getThing"}`))
+
+	// labels are not sent
+	buf.Reset()
+	var result struct {
+		Headers map[string][]string `json:"headers"`
+	}
+	resp, err = client.JSON(request.NewRequest(context.Background(), "/headers"), &result)
+	assert.NoError(t, err)
+	assert.Nil(t, resp.Labels)
+	assert.NotContains(t, buf.String(), `"labels"`)
+	assert.NotContains(t, result.Headers, "Operation")
+}
@@ -0,0 +1,33 @@
+package apik
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// requestMetrics receives measurements of every attempt to send a request, see WithMetrics
+type requestMetrics interface {
+	// inFlight changes the number of requests in flight by delta
+	inFlight(method, host string, labels map[string]string, delta float64)
+	// observe records the result of the request. The status is "error" if the request failed,
+	// the size is -1 if the size of the response body is unknown. The labels are set with reqopt.Label
+	observe(method, host, status string, labels map[string]string, duration time.Duration, size int64)
+}
+
+// recordMetrics measures the attempt to send the http.Request with the send function
+func (c *Client) recordMetrics(rawReq *http.Request, send func() (*http.Response, error)) (resp *http.Response, err error) {
+	method, host, labels := rawReq.Method, rawReq.URL.Host, requestLabels(rawReq.Context())
+	c.metrics.inFlight(method, host, labels, 1)
+	start := time.Now()
+
+	resp, err = send()
+
+	c.metrics.inFlight(method, host, labels, -1)
+	status, size := "error", int64(-1)
+	if err == nil {
+		status, size = strconv.Itoa(resp.StatusCode), resp.ContentLength
+	}
+	c.metrics.observe(method, host, status, labels, time.Since(start), size)
+	return
+}
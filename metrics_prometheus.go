@@ -0,0 +1,184 @@
+//go:build prometheus
+
+package apik
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ErrInvalidMetricsLabel is returned by requests of a client, whose MetricsLabels are reserved or repeated
+var ErrInvalidMetricsLabel = errors.New("invalid metrics label")
+
+// reservedMetricsLabels are the labels of every metric, they can't be added with MetricsLabels
+var reservedMetricsLabels = []string{"method", "host", "code"}
+
+// metricsConfig represents settings of the Prometheus metrics
+type metricsConfig struct {
+	namespace       string
+	durationBuckets []float64
+	sizeBuckets     []float64
+	labels          []string
+}
+
+// MetricsOption changes settings of the Prometheus metrics, see WithMetrics
+type MetricsOption func(*metricsConfig)
+
+// MetricsNamespace sets the namespace (prefix) of the metric names. Default is "apik".
+func MetricsNamespace(namespace string) MetricsOption {
+	return func(c *metricsConfig) {
+		c.namespace = namespace
+	}
+}
+
+// MetricsDurationBuckets sets the buckets of the request duration histogram, in seconds.
+// Default is prometheus.DefBuckets, from 5ms to 10s.
+func MetricsDurationBuckets(buckets []float64) MetricsOption {
+	return func(c *metricsConfig) {
+		c.durationBuckets = buckets
+	}
+}
+
+// MetricsSizeBuckets sets the buckets of the response size histogram, in bytes. Default is from 100B to 10MB.
+func MetricsSizeBuckets(buckets []float64) MetricsOption {
+	return func(c *metricsConfig) {
+		c.sizeBuckets = buckets
+	}
+}
+
+// MetricsLabels adds labels with the given keys to the metrics, with values from the request labels
+// set with reqopt.Label, e.g. `operation`. Requests without the label have an empty value.
+// The keys must not be "method", "host" or "code", otherwise requests fail with ErrInvalidMetricsLabel.
+func MetricsLabels(keys ...string) MetricsOption {
+	return func(c *metricsConfig) {
+		c.labels = append(c.labels, keys...)
+	}
+}
+
+// WithMetrics records Prometheus metrics of every attempt to send a request, labeled by method, host and status code:
+//
+//	apik_requests_total                     counter of requests, code is "error" if the request failed
+//	apik_request_duration_seconds           histogram of the time to get the response headers
+//	apik_requests_in_flight                 gauge of requests waiting for the response, without the code label
+//	apik_response_size_bytes                histogram of Content-Length of responses, if it is known
+//
+// The option is available only with the `prometheus` build tag:
+//
+//	go build -tags prometheus
+//
+// Clients with the same registerer and namespace share the metrics, the buckets of the first client are used.
+// They must have the same labels: if the metrics can't be registered, every request of the client fails
+// with the registration error.
+func WithMetrics(registerer prometheus.Registerer, opts ...MetricsOption) ClientOption {
+	cfg := metricsConfig{
+		namespace:       "apik",
+		durationBuckets: prometheus.DefBuckets,
+		sizeBuckets:     prometheus.ExponentialBuckets(100, 10, 6),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return func(c *Client) {
+		metrics, err := newPrometheusMetrics(registerer, cfg)
+		if err != nil {
+			c.err = err
+			return
+		}
+		c.metrics = metrics
+	}
+}
+
+// prometheusMetrics records request metrics with Prometheus collectors
+type prometheusMetrics struct {
+	labels   []string
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inflight *prometheus.GaugeVec
+	size     *prometheus.HistogramVec
+}
+
+func newPrometheusMetrics(registerer prometheus.Registerer, cfg metricsConfig) (*prometheusMetrics, error) {
+	for i, key := range cfg.labels {
+		if slices.Contains(reservedMetricsLabels, key) || slices.Contains(cfg.labels[:i], key) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidMetricsLabel, key)
+		}
+	}
+	labels := append(slices.Clone(reservedMetricsLabels), cfg.labels...)
+	inflightLabels := append([]string{"method", "host"}, cfg.labels...)
+	m := &prometheusMetrics{
+		labels: cfg.labels,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: cfg.namespace,
+			Name:      "requests_total",
+			Help:      "Total number of HTTP requests.",
+		}, labels),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: cfg.namespace,
+			Name:      "request_duration_seconds",
+			Help:      "Duration of HTTP requests until the response headers are received.",
+			Buckets:   cfg.durationBuckets,
+		}, labels),
+		inflight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: cfg.namespace,
+			Name:      "requests_in_flight",
+			Help:      "Number of HTTP requests waiting for the response.",
+		}, inflightLabels),
+		size: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: cfg.namespace,
+			Name:      "response_size_bytes",
+			Help:      "Size of HTTP response bodies with a known length.",
+			Buckets:   cfg.sizeBuckets,
+		}, labels),
+	}
+	var err error
+	if m.requests, err = register(registerer, m.requests); err != nil {
+		return nil, err
+	}
+	if m.duration, err = register(registerer, m.duration); err != nil {
+		return nil, err
+	}
+	if m.inflight, err = register(registerer, m.inflight); err != nil {
+		return nil, err
+	}
+	if m.size, err = register(registerer, m.size); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// register registers the collector, or returns the registered one with the same description
+func register[T prometheus.Collector](registerer prometheus.Registerer, collector T) (T, error) {
+	err := registerer.Register(collector)
+	var registered prometheus.AlreadyRegisteredError
+	if errors.As(err, &registered) {
+		if existing, ok := registered.ExistingCollector.(T); ok {
+			return existing, nil
+		}
+	}
+	return collector, err
+}
+
+func (m *prometheusMetrics) inFlight(method, host string, labels map[string]string, delta float64) {
+	m.inflight.WithLabelValues(m.labelValues(labels, method, host)...).Add(delta)
+}
+
+func (m *prometheusMetrics) observe(method, host, status string, labels map[string]string, duration time.Duration, size int64) {
+	values := m.labelValues(labels, method, host, status)
+	m.requests.WithLabelValues(values...).Inc()
+	m.duration.WithLabelValues(values...).Observe(duration.Seconds())
+	if size >= 0 {
+		m.size.WithLabelValues(values...).Observe(float64(size))
+	}
+}
+
+// labelValues appends values of the configured request labels to the values of the standard labels
+func (m *prometheusMetrics) labelValues(labels map[string]string, values ...string) []string {
+	for _, key := range m.labels {
+		values = append(values, labels[key])
+	}
+	return values
+}
@@ -0,0 +1,78 @@
+//go:build prometheus
+
+package apik
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/niklak/apik/reqopt"
+	"github.com/niklak/apik/request"
+	"github.com/niklak/httpbulb"
+)
+
+func TestClient_PrometheusMetrics(t *testing.T) {
+	testServer := httptest.NewServer(httpbulb.NewRouter())
+	defer testServer.Close()
+
+	registry := prometheus.NewRegistry()
+	client := New(WithBaseUrl(testServer.URL), WithMetrics(registry))
+	// the second client shares the metrics
+	other := New(WithBaseUrl(testServer.URL), WithMetrics(registry, MetricsDurationBuckets([]float64{0.1, 1})))
+
+	_, err := client.Fetch(request.NewRequest(context.Background(), "/status/200"), nil)
+	require.NoError(t, err)
+	_, err = other.Fetch(request.NewRequest(context.Background(), "/status/404"), nil)
+	require.NoError(t, err)
+
+	host := testServer.Listener.Addr().String()
+	metrics := client.metrics.(*prometheusMetrics)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.requests.WithLabelValues("GET", host, "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.requests.WithLabelValues("GET", host, "404")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.inflight.WithLabelValues("GET", host)))
+	count, err := testutil.GatherAndCount(registry, "apik_requests_total", "apik_request_duration_seconds")
+	require.NoError(t, err)
+	assert.Equal(t, 4, count)
+}
+
+func TestClient_PrometheusMetricsLabels(t *testing.T) {
+	testServer := httptest.NewServer(httpbulb.NewRouter())
+	defer testServer.Close()
+
+	registry := prometheus.NewRegistry()
+	client := New(WithBaseUrl(testServer.URL), WithMetrics(registry, MetricsLabels("operation")))
+
+	_, err := client.Fetch(request.NewRequest(context.Background(), "/status/200", reqopt.Label("operation", "getStatus")), nil)
+	require.NoError(t, err)
+	_, err = client.Fetch(request.NewRequest(context.Background(), "/status/200"), nil)
+	require.NoError(t, err)
+
+	host := testServer.Listener.Addr().String()
+	metrics := client.metrics.(*prometheusMetrics)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.requests.WithLabelValues("GET", host, "200", "getStatus")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.requests.WithLabelValues("GET", host, "200", "")))
+}
+
+func TestClient_PrometheusMetricsInvalidLabels(t *testing.T) {
+	testServer := httptest.NewServer(httpbulb.NewRouter())
+	defer testServer.Close()
+
+	registry := prometheus.NewRegistry()
+	for _, keys := range [][]string{{"host"}, {"operation", "operation"}} {
+		client := New(WithBaseUrl(testServer.URL), WithMetrics(registry, MetricsLabels(keys...)))
+		_, err := client.Fetch(request.NewRequest(context.Background(), "/status/200"), nil)
+		assert.ErrorIs(t, err, ErrInvalidMetricsLabel)
+	}
+
+	// the shared metrics have other labels
+	New(WithMetrics(registry))
+	client := New(WithBaseUrl(testServer.URL), WithMetrics(registry, MetricsLabels("operation")))
+	_, err := client.Fetch(request.NewRequest(context.Background(), "/status/200"), nil)
+	assert.ErrorContains(t, err, "different label names")
+}
@@ -0,0 +1,65 @@
+package apik
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/niklak/apik/reqopt"
+	"github.com/niklak/apik/request"
+	"github.com/niklak/httpbulb"
+)
+
+type observation struct {
+	method, host, status string
+	size                 int64
+	labels               map[string]string
+}
+
+type fakeMetrics struct {
+	mu           sync.Mutex
+	inflight     float64
+	maxInflight  float64
+	observations []observation
+}
+
+func (m *fakeMetrics) inFlight(method, host string, labels map[string]string, delta float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inflight += delta
+	m.maxInflight = max(m.maxInflight, m.inflight)
+}
+
+func (m *fakeMetrics) observe(method, host, status string, labels map[string]string, duration time.Duration, size int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.observations = append(m.observations, observation{method, host, status, size, labels})
+}
+
+func TestClient_Metrics(t *testing.T) {
+	testServer := httptest.NewServer(httpbulb.NewRouter())
+
+	metrics := &fakeMetrics{}
+	client := New(WithBaseUrl(testServer.URL))
+	client.metrics = metrics
+
+	_, err := client.Fetch(request.NewRequest(context.Background(), "/bytes/100"), nil)
+	require.NoError(t, err)
+
+	host := testServer.Listener.Addr().String()
+	testServer.Close()
+	_, err = client.Fetch(request.NewRequest(context.Background(), "/status/200", reqopt.Label("operation", "status")), nil)
+	require.Error(t, err)
+
+	assert.Equal(t, []observation{
+		{"GET", host, "200", 100, nil},
+		{"GET", host, "error", -1, map[string]string{"operation": "status"}},
+	}, metrics.observations)
+	assert.Equal(t, 1.0, metrics.maxInflight)
+	assert.Zero(t, metrics.inflight)
+}
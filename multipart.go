@@ -0,0 +1,75 @@
+package apik
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+// Part represents one part of a multipart response
+type Part struct {
+	// Header is the MIME header of the part
+	Header textproto.MIMEHeader
+	// Body is the content of the part
+	Body []byte
+}
+
+// Multipart sends an http.Request built from Request and parses the multipart response body,
+// e.g. `multipart/mixed` or `multipart/form-data`, with the boundary from the response Content-Type.
+// Parts are read one by one, use MultipartEach to handle huge parts without buffering them.
+func (c *Client) Multipart(req *Request) (parts []Part, resp *Response, err error) {
+	resp, err = c.MultipartEach(req, func(part *multipart.Part) error {
+		body, err := io.ReadAll(part)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, Part{Header: part.Header, Body: body})
+		return nil
+	})
+	return
+}
+
+// MultipartEach sends an http.Request built from Request and passes every part of the multipart response
+// to the handler, until the body ends, the handler returns an error, or the request context is cancelled.
+// The part is valid only until the handler returns.
+// If the response is not a multipart, ErrNotMultipart is returned.
+func (c *Client) MultipartEach(req *Request, handler func(part *multipart.Part) error) (resp *Response, err error) {
+	var rawResp *http.Response
+	var attempts int
+	if rawResp, attempts, err = c.doStream(req); err != nil {
+		return
+	}
+	defer drainAndClose(rawResp.Body)
+	resp = c.newResponse(rawResp, attempts)
+
+	contentType := rawResp.Header.Get("Content-Type")
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	if !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		err = fmt.Errorf("%w: %q", ErrNotMultipart, contentType)
+		return
+	}
+
+	// NextRawPart keeps Content-Transfer-Encoding of the part as is
+	reader := multipart.NewReader(resp.bodyReader(req.Ctx, rawResp.Body), params["boundary"])
+	for {
+		var part *multipart.Part
+		part, err = reader.NextRawPart()
+		if errors.Is(err, io.EOF) {
+			err = nil
+			return
+		}
+		if err != nil {
+			return
+		}
+		err = handler(part)
+		part.Close()
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package apik
+
+import (
+	"context"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/niklak/apik/request"
+)
+
+func TestClient_Multipart(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/plain" {
+			w.Write([]byte("plain"))
+			return
+		}
+		mw := multipart.NewWriter(w)
+		w.Header().Set("Content-Type", "multipart/mixed; boundary="+mw.Boundary())
+
+		part, _ := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"application/json"}})
+		part.Write([]byte(`{"id":1}`))
+		part, _ = mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain"}, "Content-Id": {"<2>"}})
+		part.Write([]byte("second part"))
+		mw.Close()
+	}))
+	defer server.Close()
+
+	client := New(WithBaseUrl(server.URL))
+
+	parts, resp, err := client.Multipart(request.NewRequest(context.Background(), "/batch"))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Len(t, parts, 2)
+
+	assert.Equal(t, "application/json", parts[0].Header.Get("Content-Type"))
+	assert.Equal(t, `{"id":1}`, string(parts[0].Body))
+	assert.Equal(t, "<2>", parts[1].Header.Get("Content-Id"))
+	assert.Equal(t, "second part", string(parts[1].Body))
+
+	// handler error stops reading
+	errStop := errors.New("stop")
+	count := 0
+	_, err = client.MultipartEach(
+		request.NewRequest(context.Background(), "/batch"),
+		func(part *multipart.Part) error {
+			count++
+			return errStop
+		},
+	)
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, 1, count)
+
+	_, _, err = client.Multipart(request.NewRequest(context.Background(), "/plain"))
+	assert.ErrorIs(t, err, ErrNotMultipart)
+}
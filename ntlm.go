@@ -0,0 +1,106 @@
+package apik
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/niklak/apik/internal/ntlm"
+)
+
+// ntlmTransport is an http.RoundTripper that performs the NTLM handshake
+// (negotiate, challenge, authenticate) on every request.
+type ntlmTransport struct {
+	rt       http.RoundTripper
+	domain   string
+	username string
+	password string
+}
+
+func (t *ntlmTransport) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	rt := t.rt
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+
+	// the body must be sent on every step of the handshake,
+	// so each step gets a fresh copy from GetBody
+	if req.Body != nil {
+		if req.GetBody == nil {
+			var body []byte
+			if body, err = io.ReadAll(req.Body); err != nil {
+				req.Body.Close()
+				return
+			}
+			req = req.Clone(req.Context())
+			req.GetBody = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(body)), nil
+			}
+		}
+		req.Body.Close()
+	}
+
+	negotiateReq, err := t.cloneRequest(req, ntlm.NegotiateMessage())
+	if err != nil {
+		return
+	}
+	if resp, err = rt.RoundTrip(negotiateReq); err != nil {
+		return
+	}
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		return
+	}
+
+	challengeMsg, ok := ntlmChallenge(resp.Header)
+	if !ok {
+		return
+	}
+
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	ch, err := ntlm.ParseChallenge(challengeMsg)
+	if err != nil {
+		return nil, err
+	}
+	authenticateMsg, err := ntlm.AuthenticateMessage(ch, t.domain, t.username, t.password)
+	if err != nil {
+		return nil, err
+	}
+
+	authReq, err := t.cloneRequest(req, authenticateMsg)
+	if err != nil {
+		return nil, err
+	}
+	return rt.RoundTrip(authReq)
+}
+
+// cloneRequest returns a copy of the request with the NTLM message in the Authorization header
+func (t *ntlmTransport) cloneRequest(req *http.Request, msg []byte) (r *http.Request, err error) {
+	r = req.Clone(req.Context())
+	if req.GetBody != nil {
+		if r.Body, err = req.GetBody(); err != nil {
+			return
+		}
+	}
+	r.Header.Set("Authorization", "NTLM "+base64.StdEncoding.EncodeToString(msg))
+	return
+}
+
+// ntlmChallenge extracts the NTLM challenge message from the `WWW-Authenticate` headers
+func ntlmChallenge(header http.Header) (msg []byte, ok bool) {
+	for _, value := range header.Values("WWW-Authenticate") {
+		scheme, data, _ := strings.Cut(strings.TrimSpace(value), " ")
+		if !strings.EqualFold(scheme, "NTLM") || data == "" {
+			continue
+		}
+		var err error
+		if msg, err = base64.StdEncoding.DecodeString(data); err == nil {
+			return msg, true
+		}
+	}
+	return
+}
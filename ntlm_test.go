@@ -0,0 +1,128 @@
+package apik
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/niklak/apik/internal/ntlm"
+	"github.com/niklak/apik/reqopt"
+	"github.com/niklak/apik/request"
+)
+
+// ntlmField reads a variable-length field of the NTLM message
+func ntlmField(msg []byte, pos int) []byte {
+	length := int(binary.LittleEndian.Uint16(msg[pos:]))
+	offset := int(binary.LittleEndian.Uint32(msg[pos+4:]))
+	return msg[offset : offset+length]
+}
+
+func newNTLMServer(domain, user, password string) *httptest.Server {
+	serverChallenge := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		auth := strings.TrimPrefix(r.Header.Get("Authorization"), "NTLM ")
+		msg, _ := base64.StdEncoding.DecodeString(auth)
+
+		if len(msg) < 12 {
+			w.Header().Set("WWW-Authenticate", "NTLM")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch binary.LittleEndian.Uint32(msg[8:]) {
+		case 1:
+			challenge := make([]byte, 48)
+			copy(challenge, "NTLMSSP\x00")
+			binary.LittleEndian.PutUint32(challenge[8:], 2)
+			binary.LittleEndian.PutUint32(challenge[20:], 0x00088207)
+			copy(challenge[24:], serverChallenge)
+			// empty target info, terminated with MsvAvEOL
+			binary.LittleEndian.PutUint16(challenge[40:], 4)
+			binary.LittleEndian.PutUint16(challenge[42:], 4)
+			binary.LittleEndian.PutUint32(challenge[44:], 48)
+			challenge = append(challenge, 0, 0, 0, 0)
+
+			w.Header().Set("WWW-Authenticate", "NTLM "+base64.StdEncoding.EncodeToString(challenge))
+			w.WriteHeader(http.StatusUnauthorized)
+		case 3:
+			ntResponse := ntlmField(msg, 20)
+			key := ntlm.NTOWFv2(password, user, domain)
+			mac := hmac.New(md5.New, key)
+			mac.Write(serverChallenge)
+			mac.Write(ntResponse[16:])
+			if !bytes.Equal(mac.Sum(nil), ntResponse[:16]) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write(body)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+}
+
+func TestClient_NTLMAuth(t *testing.T) {
+	server := newNTLMServer("DOMAIN", "user", "passwd")
+	defer server.Close()
+
+	client := New(
+		WithBaseUrl(server.URL),
+		WithNTLMAuth("DOMAIN", "user", "passwd"),
+	)
+
+	var result string
+	resp, err := client.Fetch(
+		request.NewRequest(
+			context.Background(),
+			"/",
+			reqopt.Method(http.MethodPost),
+			reqopt.SetText("payload"),
+		),
+		&result,
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "payload", result)
+
+	// wrong password
+	client = New(
+		WithBaseUrl(server.URL),
+		WithNTLMAuth("DOMAIN", "user", "wrong"),
+	)
+	resp, err = client.Fetch(request.NewRequest(context.Background(), "/"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+}
+
+func TestClient_NTLMAuthSharedHttpClient(t *testing.T) {
+	server := newNTLMServer("DOMAIN", "user", "passwd")
+	defer server.Close()
+
+	// the given http.Client is shared between clients with different credentials
+	hc := server.Client()
+	transport := hc.Transport
+	client := New(WithBaseUrl(server.URL), WithHttpClient(hc), WithNTLMAuth("DOMAIN", "user", "passwd"))
+	other := New(WithBaseUrl(server.URL), WithHttpClient(hc), WithNTLMAuth("DOMAIN", "user", "wrong"))
+	assert.Equal(t, transport, hc.Transport)
+	assert.Nil(t, hc.Jar)
+
+	resp, err := client.Fetch(request.NewRequest(context.Background(), "/"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, err = other.Fetch(request.NewRequest(context.Background(), "/"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+}
@@ -0,0 +1,29 @@
+package apik
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// FetchPretty sends an http.Request built from Request and returns the response body for human-readable output.
+// A JSON body is re-indented with two spaces, other bodies are returned as is.
+// The body is treated as JSON if the Content-Type says so, or if it parses as JSON.
+// It buffers the whole body, so it's meant for debugging and CLI tools, not for decoding.
+func (c *Client) FetchPretty(req *Request) (pretty string, resp *Response, err error) {
+	var body []byte
+	if resp, err = c.Fetch(req, &body); err != nil {
+		return
+	}
+	pretty = string(body)
+
+	contentType := resp.Raw.Header.Get("Content-Type")
+	if !strings.Contains(contentType, "json") && !json.Valid(body) {
+		return
+	}
+	buf := new(bytes.Buffer)
+	if json.Indent(buf, body, "", "  ") == nil {
+		pretty = buf.String()
+	}
+	return
+}
@@ -0,0 +1,53 @@
+package apik
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/niklak/apik/request"
+)
+
+func TestClient_FetchPretty(t *testing.T) {
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name":"apik","tags":["a","b"]}`))
+		case "/untyped":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte(`[1,{"a":null}]`))
+		case "/broken":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name":`))
+		default:
+			w.Write([]byte("plain text"))
+		}
+	}))
+	defer testServer.Close()
+
+	client := New(WithBaseUrl(testServer.URL))
+
+	pretty, resp, err := client.FetchPretty(request.NewRequest(context.Background(), "/json"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "{\n  \"name\": \"apik\",\n  \"tags\": [\n    \"a\",\n    \"b\"\n  ]\n}", pretty)
+
+	// JSON is detected by parsing the body
+	pretty, _, err = client.FetchPretty(request.NewRequest(context.Background(), "/untyped"))
+	assert.NoError(t, err)
+	assert.Equal(t, "[\n  1,\n  {\n    \"a\": null\n  }\n]", pretty)
+
+	// invalid JSON and other bodies are returned as is
+	pretty, _, err = client.FetchPretty(request.NewRequest(context.Background(), "/broken"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":`, pretty)
+
+	pretty, _, err = client.FetchPretty(request.NewRequest(context.Background(), "/text"))
+	assert.NoError(t, err)
+	assert.Equal(t, "plain text", pretty)
+}
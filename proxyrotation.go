@@ -0,0 +1,169 @@
+package apik
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// proxyMaxFailures is the number of consecutive failures after which a rotated proxy is skipped
+	proxyMaxFailures = 3
+	// proxyCooldown is the time a failed proxy is skipped for
+	proxyCooldown = 30 * time.Second
+)
+
+// RotationStrategy defines how WithProxyRotation picks a proxy for a request
+type RotationStrategy int
+
+const (
+	// RoundRobin picks proxies in turn
+	RoundRobin RotationStrategy = iota
+	// RandomProxy picks a random proxy for every request
+	RandomProxy
+)
+
+// rotatedProxy is a proxy of the rotation with its health
+type rotatedProxy struct {
+	url       *url.URL
+	failures  int
+	deadUntil time.Time
+}
+
+// proxyRotation picks a proxy for every attempt to send a request and skips proxies that keep failing
+type proxyRotation struct {
+	strategy RotationStrategy
+	err      error
+
+	mu      sync.Mutex
+	proxies []*rotatedProxy
+	next    int
+}
+
+// usedProxy keeps the index of the proxy picked for the attempt, it is passed with the request context
+type usedProxy struct {
+	index atomic.Int32
+}
+
+type usedProxyKey struct{}
+
+// WithProxyRotation sends requests through the proxies, picking one for every attempt with the strategy,
+// so retries go through another proxy. A proxy is skipped for 30 seconds after 3 consecutive failed attempts,
+// if all proxies are skipped, all of them are used again. The proxy of the final attempt is available
+// as Response.Proxy. The transport keeps a separate connection pool for every proxy.
+// If a proxy URL can't be parsed, requests fail with the parse error instead of bypassing the proxies.
+func WithProxyRotation(proxies []string, strategy RotationStrategy) ClientOption {
+	return func(c *Client) {
+		rotation := &proxyRotation{strategy: strategy}
+		for _, proxy := range proxies {
+			u, err := url.Parse(proxy)
+			if err != nil {
+				rotation.err = err
+				break
+			}
+			rotation.proxies = append(rotation.proxies, &rotatedProxy{url: u})
+		}
+		if len(rotation.proxies) == 0 && rotation.err == nil {
+			rotation.err = ErrNoProxies
+		}
+		c.proxyRotation = rotation
+		c.transportOpts = append(c.transportOpts, func(t *http.Transport) {
+			t.Proxy = rotation.proxy
+		})
+	}
+}
+
+// proxy picks the proxy for the http.Request, it's used as http.Transport.Proxy
+func (p *proxyRotation) proxy(req *http.Request) (*url.URL, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	i := p.pick()
+	if used, ok := req.Context().Value(usedProxyKey{}).(*usedProxy); ok {
+		used.index.Store(int32(i) + 1)
+	}
+	return p.proxies[i].url, nil
+}
+
+// pick returns the index of the next proxy, that is not skipped
+func (p *proxyRotation) pick() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	alive := make([]int, 0, len(p.proxies))
+	for i, proxy := range p.proxies {
+		if !now.Before(proxy.deadUntil) {
+			alive = append(alive, i)
+		}
+	}
+	if len(alive) == 0 {
+		for i := range p.proxies {
+			alive = append(alive, i)
+		}
+	}
+
+	if p.strategy == RandomProxy {
+		return alive[rand.N(len(alive))]
+	}
+	// the first alive proxy starting from the next one in turn
+	for _, i := range alive {
+		if i >= p.next {
+			p.next = i + 1
+			return i
+		}
+	}
+	p.next = alive[0] + 1
+	return alive[0]
+}
+
+// record records the result of the attempt sent through the proxy with the index
+func (p *proxyRotation) record(i int, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	proxy := p.proxies[i]
+	// the caller gave up, it says nothing about the proxy
+	if IsErrorKind(err, KindCanceled) {
+		return
+	}
+	if err == nil {
+		proxy.failures = 0
+		return
+	}
+	proxy.failures++
+	if proxy.failures >= proxyMaxFailures {
+		proxy.failures = 0
+		proxy.deadUntil = time.Now().Add(proxyCooldown)
+	}
+}
+
+// withUsedProxy returns a copy of the http.Request, that records the proxy picked for it
+func withUsedProxy(rawReq *http.Request) (*http.Request, *usedProxy) {
+	used := new(usedProxy)
+	return rawReq.WithContext(context.WithValue(rawReq.Context(), usedProxyKey{}, used)), used
+}
+
+// recordUsed records the result of the attempt, if a proxy was picked for it
+func (p *proxyRotation) recordUsed(used *usedProxy, err error) {
+	if i := int(used.index.Load()) - 1; i >= 0 {
+		p.record(i, err)
+	}
+}
+
+// proxyOf returns the redacted URL of the proxy, that was used for the http.Request
+func (c *Client) proxyOf(rawReq *http.Request) string {
+	used, ok := rawReq.Context().Value(usedProxyKey{}).(*usedProxy)
+	if !ok {
+		return ""
+	}
+	i := int(used.index.Load()) - 1
+	if i < 0 {
+		return ""
+	}
+	return c.proxyRotation.proxies[i].url.Redacted()
+}
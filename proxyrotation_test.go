@@ -0,0 +1,74 @@
+package apik
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/niklak/apik/internal/proxy"
+	"github.com/niklak/apik/request"
+	"github.com/niklak/httpbulb"
+)
+
+func TestClient_ProxyRotation(t *testing.T) {
+
+	target := httptest.NewTLSServer(httpbulb.NewRouter())
+	defer target.Close()
+
+	first := httptest.NewServer(http.HandlerFunc(proxy.HttpProxyConnectHandler))
+	defer first.Close()
+	second := httptest.NewServer(http.HandlerFunc(proxy.HttpProxyConnectHandler))
+	defer second.Close()
+
+	newClient := func(proxies []string, opts ...ClientOption) *Client {
+		opts = append([]ClientOption{
+			WithBaseUrl(target.URL),
+			WithHttpClient(target.Client()),
+			WithProxyRotation(proxies, RoundRobin),
+		}, opts...)
+		return New(opts...)
+	}
+
+	client := newClient([]string{first.URL, second.URL})
+	var used []string
+	for range 4 {
+		resp, err := client.Fetch(request.NewRequest(context.Background(), "/get"), nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		used = append(used, resp.Proxy)
+	}
+	assert.Equal(t, []string{first.URL, second.URL, first.URL, second.URL}, used)
+
+	// a dead proxy is retried through the next one, and is skipped after consecutive failures
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close()
+
+	client = newClient([]string{dead.URL, first.URL}, WithRetry(2, nil))
+	for range proxyMaxFailures {
+		resp, err := client.Fetch(request.NewRequest(context.Background(), "/get"), nil)
+		require.NoError(t, err)
+		assert.Equal(t, 2, resp.Attempts)
+		assert.Equal(t, first.URL, resp.Proxy)
+	}
+	resp, err := client.Fetch(request.NewRequest(context.Background(), "/get"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, resp.Attempts)
+	assert.Equal(t, first.URL, resp.Proxy)
+
+	// random proxies
+	client = New(
+		WithBaseUrl(target.URL),
+		WithHttpClient(target.Client()),
+		WithProxyRotation([]string{first.URL, second.URL}, RandomProxy),
+	)
+	resp, err = client.Fetch(request.NewRequest(context.Background(), "/get"), nil)
+	require.NoError(t, err)
+	assert.Contains(t, []string{first.URL, second.URL}, resp.Proxy)
+
+	_, err = newClient(nil).Fetch(request.NewRequest(context.Background(), "/get"), nil)
+	assert.ErrorIs(t, err, ErrNoProxies)
+}
@@ -0,0 +1,138 @@
+// Package proxytest provides HTTP proxies for testing the proxy behavior of HTTP clients.
+package proxytest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/niklak/apik/internal/proxy"
+)
+
+// Proxy is a test HTTP proxy that tunnels CONNECT requests to their targets
+// and forwards plain HTTP requests, e.g. requests to `http://` URLs.
+// It records the number of received requests and the authority of the last one.
+// Plain HTTP requests are captured, see Captured.
+type Proxy struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	requests      int
+	lastAuthority string
+	captured      []CapturedRequest
+	handler       http.Handler
+	cancel        context.CancelFunc
+	// handlers tracks running handlers, including tunnels of hijacked connections, that httptest.Server doesn't wait for
+	handlers sync.WaitGroup
+}
+
+// CapturedRequest is a plain HTTP request received by the proxy
+type CapturedRequest struct {
+	Method string
+	// URL is the absolute URL of the request
+	URL    string
+	Header http.Header
+	// Body is the request body, decompressed if it was sent with `Content-Encoding: gzip`
+	Body []byte
+}
+
+// NewConnectProxy starts and returns a new CONNECT proxy without authentication.
+// The caller should call Close when finished, to shut it down.
+func NewConnectProxy() *Proxy {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Proxy{handler: proxy.ConnectHandler(ctx), cancel: cancel}
+	p.Server = httptest.NewServer(http.HandlerFunc(p.serveHTTP))
+	return p
+}
+
+// NewConnectProxyWithAuth starts and returns a new CONNECT proxy, that requires basic authentication
+// with the `Proxy-Authorization` header. Without valid credentials it responds with 407 and `Proxy-Authenticate`.
+// The caller should call Close when finished, to shut it down.
+func NewConnectProxyWithAuth(username, password string) *Proxy {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Proxy{handler: proxy.HttpProxyConnectAuthHandler(ctx, username, password), cancel: cancel}
+	p.Server = httptest.NewServer(http.HandlerFunc(p.serveHTTP))
+	return p
+}
+
+// Close shuts down the proxy, including established tunnels, and blocks until all requests are finished
+func (p *Proxy) Close() {
+	p.cancel()
+	p.Server.Close()
+	p.handlers.Wait()
+}
+
+// Requests returns the number of requests received by the proxy, including rejected ones
+func (p *Proxy) Requests() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.requests
+}
+
+// LastAuthority returns the target `host:port` of the last request received by the proxy
+func (p *Proxy) LastAuthority() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lastAuthority
+}
+
+// Captured returns the plain HTTP requests received by the proxy, including rejected ones.
+// Requests tunneled with CONNECT are encrypted end to end, so they can't be captured.
+func (p *Proxy) Captured() []CapturedRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]CapturedRequest(nil), p.captured...)
+}
+
+func (p *Proxy) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	p.handlers.Add(1)
+	defer p.handlers.Done()
+
+	var captured *CapturedRequest
+	if r.Method != http.MethodConnect {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		// the body is forwarded as it was received
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		captured = &CapturedRequest{
+			Method: r.Method,
+			URL:    r.URL.String(),
+			Header: r.Header.Clone(),
+			Body:   decodeBody(r.Header.Get("Content-Encoding"), body),
+		}
+	}
+
+	p.mu.Lock()
+	p.requests++
+	p.lastAuthority = r.URL.Host
+	if captured != nil {
+		p.captured = append(p.captured, *captured)
+	}
+	p.mu.Unlock()
+
+	p.handler.ServeHTTP(w, r)
+}
+
+// decodeBody decompresses a gzip-encoded body. Other bodies and invalid gzip data are returned as is.
+func decodeBody(encoding string, body []byte) []byte {
+	if !strings.EqualFold(strings.TrimSpace(encoding), "gzip") {
+		return body
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return body
+	}
+	decoded, err := io.ReadAll(zr)
+	if err != nil {
+		return body
+	}
+	return decoded
+}
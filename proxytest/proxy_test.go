@@ -0,0 +1,211 @@
+package proxytest
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/niklak/apik"
+	"github.com/niklak/apik/reqopt"
+	"github.com/niklak/apik/request"
+	"github.com/niklak/httpbulb"
+)
+
+func proxiedClient(target *httptest.Server, proxyURL string) *apik.Client {
+	return apik.New(apik.WithBaseUrl(target.URL), apik.WithHttpClient(target.Client()), apik.WithProxy(proxyURL))
+}
+
+func TestConnectProxy(t *testing.T) {
+	target := httptest.NewTLSServer(httpbulb.NewRouter())
+	defer target.Close()
+
+	p := NewConnectProxy()
+	defer p.Close()
+
+	client := proxiedClient(target, p.URL)
+	resp, err := client.Fetch(request.NewRequest(context.Background(), "/get"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Equal(t, 1, p.Requests())
+	assert.Equal(t, target.Listener.Addr().String(), p.LastAuthority())
+}
+
+func TestConnectProxyWithAuth(t *testing.T) {
+	target := httptest.NewTLSServer(httpbulb.NewRouter())
+	defer target.Close()
+
+	p := NewConnectProxyWithAuth("user", "secret")
+	defer p.Close()
+
+	u, err := url.Parse(p.URL)
+	require.NoError(t, err)
+
+	client := proxiedClient(target, u.String())
+	_, err = client.Fetch(request.NewRequest(context.Background(), "/get"), nil)
+	assert.ErrorContains(t, err, "Proxy Authentication Required")
+
+	u.User = url.UserPassword("user", "wrong")
+	client = proxiedClient(target, u.String())
+	_, err = client.Fetch(request.NewRequest(context.Background(), "/get"), nil)
+	assert.Error(t, err)
+
+	u.User = url.UserPassword("user", "secret")
+	client = proxiedClient(target, u.String())
+	resp, err := client.Fetch(request.NewRequest(context.Background(), "/get"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Equal(t, 3, p.Requests())
+
+	client = proxiedClient(target, "://"+p.Listener.Addr().String())
+	_, err = client.Fetch(request.NewRequest(context.Background(), "/get"), nil)
+	assert.ErrorContains(t, err, "missing protocol scheme")
+	assert.Equal(t, 3, p.Requests())
+}
+
+func TestConnectProxy_Close(t *testing.T) {
+	// the target accepts connections and never responds
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer target.Close()
+	go func() {
+		for {
+			conn, err := target.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	p := NewConnectProxy()
+
+	conn, err := net.Dial("tcp", p.Listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = fmt.Fprintf(conn, "CONNECT %[1]s HTTP/1.1\r\nHost: %[1]s\r\n\r\n", target.Addr())
+	require.NoError(t, err)
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	closed := make(chan struct{})
+	go func() {
+		p.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("the proxy was not closed with an established tunnel")
+	}
+
+	_, err = io.ReadAll(conn)
+	assert.NoError(t, err)
+}
+
+func TestConnectProxy_CloseForwarded(t *testing.T) {
+	// the target never responds, until the forwarded request is canceled
+	release := make(chan struct{})
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer target.Close()
+	defer close(release)
+
+	p := NewConnectProxy()
+
+	client := apik.New(apik.WithBaseUrl(target.URL), apik.WithProxy(p.URL))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		client.Fetch(request.NewRequest(context.Background(), "/"), nil)
+	}()
+	assert.Eventually(t, func() bool { return p.Requests() == 1 }, time.Second, 10*time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		p.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("the proxy was not closed with a forwarded request")
+	}
+	<-done
+}
+
+func TestConnectProxy_Captured(t *testing.T) {
+	target := httptest.NewServer(httpbulb.NewRouter())
+	defer target.Close()
+
+	p := NewConnectProxyWithAuth("user", "secret")
+	defer p.Close()
+
+	u, err := url.Parse(p.URL)
+	require.NoError(t, err)
+	u.User = url.UserPassword("user", "secret")
+
+	// the client has no option to compress request bodies yet, the end-to-end check with it is a follow-up,
+	// so the body is compressed here
+	buf := new(bytes.Buffer)
+	zw := gzip.NewWriter(buf)
+	_, err = zw.Write([]byte(`{"name":"apik"}`))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	client := apik.New(apik.WithBaseUrl(target.URL), apik.WithProxy(u.String()))
+	var result struct {
+		Headers map[string][]string `json:"headers"`
+	}
+	resp, err := client.JSON(request.NewRequest(
+		context.Background(),
+		"/post?q=1",
+		reqopt.Method(http.MethodPost),
+		reqopt.SetBody(buf.Bytes()),
+		reqopt.Header("Content-Type", "application/octet-stream"),
+		reqopt.Header("Content-Encoding", "gzip"),
+	), &result)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// the request is forwarded as is, without proxy credentials
+	assert.Equal(t, []string{"gzip"}, result.Headers["Content-Encoding"])
+	assert.NotContains(t, result.Headers, "Proxy-Authorization")
+
+	captured := p.Captured()
+	require.Len(t, captured, 1)
+	assert.Equal(t, http.MethodPost, captured[0].Method)
+	assert.Equal(t, target.URL+"/post?q=1", captured[0].URL)
+	assert.Equal(t, "gzip", captured[0].Header.Get("Content-Encoding"))
+	assert.JSONEq(t, `{"name":"apik"}`, string(captured[0].Body))
+
+	// CONNECT requests are not captured
+	tlsTarget := httptest.NewTLSServer(httpbulb.NewRouter())
+	defer tlsTarget.Close()
+	client = apik.New(apik.WithBaseUrl(tlsTarget.URL), apik.WithHttpClient(tlsTarget.Client()), apik.WithProxy(u.String()))
+	_, err = client.Fetch(request.NewRequest(context.Background(), "/get"), nil)
+	require.NoError(t, err)
+	assert.Len(t, p.Captured(), 1)
+	assert.Equal(t, 2, p.Requests())
+}
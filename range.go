@@ -0,0 +1,64 @@
+package apik
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ContentRange represents the `Content-Range` header of the response
+type ContentRange struct {
+	// Start is the first byte position of the range, -1 if the range was not satisfiable
+	Start int64
+	// End is the last byte position of the range, inclusive. -1 if the range was not satisfiable
+	End int64
+	// Size is the complete length of the representation, -1 if it is unknown
+	Size int64
+}
+
+// ContentRange parses the `Content-Range` header of the response, e.g. `bytes 0-99/1000`.
+// It returns false if the header is missing or malformed.
+func (r *Response) ContentRange() (cr ContentRange, ok bool) {
+	if r.Raw == nil {
+		return
+	}
+	return parseContentRange(r.Raw.Header.Get("Content-Range"))
+}
+
+func parseContentRange(value string) (cr ContentRange, ok bool) {
+	unit, spec, found := strings.Cut(strings.TrimSpace(value), " ")
+	if !found || unit != "bytes" {
+		return
+	}
+	rng, size, found := strings.Cut(spec, "/")
+	if !found {
+		return
+	}
+
+	var err error
+	if size == "*" {
+		cr.Size = -1
+	} else if cr.Size, err = strconv.ParseInt(size, 10, 64); err != nil || cr.Size < 0 {
+		return
+	}
+
+	if rng == "*" {
+		// unsatisfied range, the size must be known
+		cr.Start, cr.End = -1, -1
+		return cr, cr.Size >= 0
+	}
+
+	start, end, found := strings.Cut(rng, "-")
+	if !found {
+		return
+	}
+	if cr.Start, err = strconv.ParseInt(start, 10, 64); err != nil || cr.Start < 0 {
+		return
+	}
+	if cr.End, err = strconv.ParseInt(end, 10, 64); err != nil || cr.End < cr.Start {
+		return
+	}
+	if cr.Size >= 0 && cr.End >= cr.Size {
+		return
+	}
+	return cr, true
+}
@@ -0,0 +1,77 @@
+package apik
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/niklak/apik/reqopt"
+	"github.com/niklak/apik/request"
+	"github.com/niklak/httpbulb"
+)
+
+func TestClient_Range(t *testing.T) {
+
+	testServer := httptest.NewServer(httpbulb.NewRouter())
+	defer testServer.Close()
+
+	client := New(WithBaseUrl(testServer.URL))
+
+	tests := []struct {
+		name          string
+		start, end    int64
+		expectedBody  string
+		expectedRange ContentRange
+	}{
+		{name: "closed", start: 2, end: 5, expectedBody: "cdef", expectedRange: ContentRange{2, 5, 26}},
+		{name: "open-ended", start: 20, end: -1, expectedBody: "uvwxyz", expectedRange: ContentRange{20, 25, 26}},
+		{name: "suffix", start: -3, end: 0, expectedBody: "xyz", expectedRange: ContentRange{23, 25, 26}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body string
+			resp, err := client.Fetch(
+				request.NewRequest(context.Background(), "/range/26", reqopt.Range(tt.start, tt.end)),
+				&body,
+			)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+			assert.Equal(t, tt.expectedBody, body)
+
+			cr, ok := resp.ContentRange()
+			assert.True(t, ok)
+			assert.Equal(t, tt.expectedRange, cr)
+		})
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+
+	tests := []struct {
+		value    string
+		expected ContentRange
+		ok       bool
+	}{
+		{value: "bytes 0-99/1000", expected: ContentRange{0, 99, 1000}, ok: true},
+		{value: "bytes 0-99/*", expected: ContentRange{0, 99, -1}, ok: true},
+		{value: "bytes */1000", expected: ContentRange{-1, -1, 1000}, ok: true},
+		{value: ""},
+		{value: "bytes */*"},
+		{value: "items 0-9/10"},
+		{value: "bytes 10-5/100"},
+		{value: "bytes 0-100/100"},
+		{value: "bytes 0-/100"},
+	}
+
+	for _, tt := range tests {
+		cr, ok := parseContentRange(tt.value)
+		assert.Equal(t, tt.ok, ok, tt.value)
+		if tt.ok {
+			assert.Equal(t, tt.expected, cr, tt.value)
+		}
+	}
+}
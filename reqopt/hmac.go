@@ -0,0 +1,160 @@
+package reqopt
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/niklak/apik/request"
+)
+
+// HMACAlgorithm represents a hash algorithm used by HMACSign
+type HMACAlgorithm struct {
+	// Name is the name of the algorithm, that will be sent in the Authorization header
+	Name string
+	// New returns a new hash.Hash
+	New func() hash.Hash
+}
+
+var (
+	HMACSHA1   = HMACAlgorithm{Name: "hmac-sha1", New: sha1.New}
+	HMACSHA256 = HMACAlgorithm{Name: "hmac-sha256", New: sha256.New}
+	HMACSHA512 = HMACAlgorithm{Name: "hmac-sha512", New: sha512.New}
+)
+
+type hmacSigner struct {
+	keyID     string
+	secret    []byte
+	headers   []string
+	algorithm HMACAlgorithm
+	timestamp bool
+	nonce     bool
+}
+
+// HMACOption is a function that modifies the HMAC signer
+type HMACOption func(*hmacSigner)
+
+// WithHMACAlgorithm sets the hash algorithm of the signature. Default is HMACSHA256
+func WithHMACAlgorithm(algorithm HMACAlgorithm) HMACOption {
+	return func(s *hmacSigner) {
+		s.algorithm = algorithm
+	}
+}
+
+// WithHMACTimestamp adds the current unix timestamp to the signature
+func WithHMACTimestamp() HMACOption {
+	return func(s *hmacSigner) {
+		s.timestamp = true
+	}
+}
+
+// WithHMACNonce adds a random nonce to the signature
+func WithHMACNonce() HMACOption {
+	return func(s *hmacSigner) {
+		s.nonce = true
+	}
+}
+
+// HMACSign signs the request with HMAC and sets the Authorization header.
+//
+// The signed string consists of the following lines, joined with "\n":
+//
+//	<METHOD>
+//	<request URI: path and query>
+//	<lowercased header name>:<header value>, for every header in headers ("host" is the request host)
+//	timestamp:<unix seconds>, if WithHMACTimestamp is used
+//	nonce:<random hex>, if WithHMACNonce is used
+//	<hex encoded digest of the body, using the same hash algorithm>
+//
+// The Authorization header has the following format:
+//
+//	HMAC keyId="<keyID>",algorithm="hmac-sha256",headers="<names>",timestamp="<ts>",nonce="<nonce>",signature="<base64>"
+//
+// timestamp and nonce are present only if enabled. The request is signed again before every retry,
+// so each attempt gets a fresh timestamp and nonce.
+func HMACSign(keyID, secret string, headers []string, opts ...HMACOption) request.RequestOption {
+	s := &hmacSigner{
+		keyID:     keyID,
+		secret:    []byte(secret),
+		headers:   headers,
+		algorithm: HMACSHA256,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return AttemptHook(s.sign)
+}
+
+func (s *hmacSigner) sign(req *http.Request) (err error) {
+	var body []byte
+	if req.GetBody != nil {
+		var rc io.ReadCloser
+		if rc, err = req.GetBody(); err != nil {
+			return
+		}
+		defer rc.Close()
+		if body, err = io.ReadAll(rc); err != nil {
+			return
+		}
+	}
+
+	lines := []string{strings.ToUpper(req.Method), req.URL.RequestURI()}
+	names := make([]string, 0, len(s.headers))
+	for _, name := range s.headers {
+		name = strings.ToLower(name)
+		names = append(names, name)
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = req.Host
+			if value == "" {
+				value = req.URL.Host
+			}
+		}
+		lines = append(lines, name+":"+strings.TrimSpace(value))
+	}
+
+	params := []string{
+		fmt.Sprintf("keyId=%q", s.keyID),
+		fmt.Sprintf("algorithm=%q", s.algorithm.Name),
+		fmt.Sprintf("headers=%q", strings.Join(names, " ")),
+	}
+
+	if s.timestamp {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		lines = append(lines, "timestamp:"+ts)
+		params = append(params, fmt.Sprintf("timestamp=%q", ts))
+	}
+
+	if s.nonce {
+		b := make([]byte, 16)
+		if _, err = rand.Read(b); err != nil {
+			return
+		}
+		nonce := hex.EncodeToString(b)
+		lines = append(lines, "nonce:"+nonce)
+		params = append(params, fmt.Sprintf("nonce=%q", nonce))
+	}
+
+	bodyHash := s.algorithm.New()
+	bodyHash.Write(body)
+	lines = append(lines, hex.EncodeToString(bodyHash.Sum(nil)))
+
+	mac := hmac.New(s.algorithm.New, s.secret)
+	mac.Write([]byte(strings.Join(lines, "\n")))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	params = append(params, fmt.Sprintf("signature=%q", signature))
+
+	req.Header.Set("Authorization", "HMAC "+strings.Join(params, ","))
+	return
+}
@@ -0,0 +1,30 @@
+package reqopt
+
+import (
+	"net/http"
+
+	"github.com/niklak/apik/internal/uuid"
+	"github.com/niklak/apik/request"
+)
+
+// IdempotencyKeyHeader is the header that carries the idempotency key of the request
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotencyKey sets the `Idempotency-Key` header.
+// Retries of the request are sent with the same key, so the server can deduplicate them.
+func IdempotencyKey(key string) request.RequestOption {
+	return func(r *request.Request) {
+		r.Header.Set(IdempotencyKeyHeader, key)
+	}
+}
+
+// AutoIdempotencyKey sets the `Idempotency-Key` header to a random UUID, unless the header is already set.
+// The key is generated once per sending of the request and kept across its retries.
+func AutoIdempotencyKey() request.RequestOption {
+	return Hook(func(req *http.Request) error {
+		if req.Header.Get(IdempotencyKeyHeader) == "" {
+			req.Header.Set(IdempotencyKeyHeader, uuid.NewV4())
+		}
+		return nil
+	})
+}
@@ -0,0 +1,52 @@
+package reqopt
+
+import (
+	"encoding/json"
+
+	"github.com/niklak/apik/request"
+)
+
+// PatchOp represents one operation of a JSON Patch document (RFC 6902)
+type PatchOp struct {
+	// Op is the operation: add, remove, replace, move, copy or test
+	Op string `json:"op"`
+	// Path is the JSON Pointer to the target location
+	Path string `json:"path"`
+	// From is the JSON Pointer to the source location for move and copy operations
+	From string `json:"from,omitempty"`
+	// Value is the value for add, replace and test operations. It is always sent for them, nil as null
+	Value any `json:"value"`
+}
+
+// MarshalJSON encodes the operation, omitting the value for remove, move and copy operations
+func (op PatchOp) MarshalJSON() ([]byte, error) {
+	switch op.Op {
+	case "remove", "move", "copy":
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+			From string `json:"from,omitempty"`
+		}{op.Op, op.Path, op.From})
+	}
+	// the alias has no MarshalJSON method, so it is encoded by its tags
+	type patchOp PatchOp
+	return json.Marshal(patchOp(op))
+}
+
+// SetMergePatch sets the JSON Merge Patch document (RFC 7396) as the request body,
+// with the `application/merge-patch+json` content type.
+func SetMergePatch(patch any) request.RequestOption {
+	return func(r *request.Request) {
+		r.JSON = patch
+		r.Header.Set("Content-Type", "application/merge-patch+json")
+	}
+}
+
+// SetJSONPatch sets the JSON Patch document (RFC 6902) as the request body,
+// with the `application/json-patch+json` content type.
+func SetJSONPatch(ops []PatchOp) request.RequestOption {
+	return func(r *request.Request) {
+		r.JSON = ops
+		r.Header.Set("Content-Type", "application/json-patch+json")
+	}
+}
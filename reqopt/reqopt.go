@@ -1,8 +1,15 @@
 package reqopt
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/niklak/apik/request"
 )
@@ -21,6 +28,212 @@ func Header(key, value string) request.RequestOption {
 	}
 }
 
+// Accept sets the Accept header
+func Accept(mime string) request.RequestOption {
+	return func(r *request.Request) {
+		r.Header.Set("Accept", mime)
+	}
+}
+
+// languageRange matches a language range of RFC 4647, e.g. `en`, `en-US`, `zh-Hant-TW` or `*`
+var languageRange = regexp.MustCompile(`^(\*|[A-Za-z]{1,8}(-[A-Za-z0-9]{1,8})*)$`)
+
+// AcceptLanguage sets the Accept-Language header from the languages in the order of preference,
+// with decreasing quality values: `en-US,en;q=0.9,fr;q=0.8`.
+// A malformed language tag results in request.ErrInvalidLanguageTag.
+func AcceptLanguage(langs ...string) request.RequestOption {
+	return func(r *request.Request) {
+		// the step keeps quality values distinct and positive for any number of languages
+		step := 100
+		if len(langs) > 10 {
+			step = 900 / (len(langs) - 1)
+		}
+		values := make([]string, 0, len(langs))
+		for i, lang := range langs {
+			if !languageRange.MatchString(lang) {
+				r.Err = fmt.Errorf("%w: %q", request.ErrInvalidLanguageTag, lang)
+				return
+			}
+			if i == 0 {
+				values = append(values, lang)
+				continue
+			}
+			q := strings.TrimRight(fmt.Sprintf("%.3f", float64(1000-i*step)/1000), "0")
+			values = append(values, lang+";q="+q)
+		}
+		r.Header.Set("Accept-Language", strings.Join(values, ","))
+	}
+}
+
+// Priority sets the Priority header of RFC 9218 as a structured-fields dictionary, e.g. `u=1, i`.
+// The urgency is from 0 (highest) to 7 (lowest), 3 is the default of servers.
+// If incremental is true, the server may interleave the response with responses of the same urgency.
+// An urgency out of the range results in request.ErrInvalidPriority.
+func Priority(urgency int, incremental bool) request.RequestOption {
+	return func(r *request.Request) {
+		if urgency < 0 || urgency > 7 {
+			r.Err = fmt.Errorf("%w: %d, must be from 0 to 7", request.ErrInvalidPriority, urgency)
+			return
+		}
+		value := "u=" + strconv.Itoa(urgency)
+		if incremental {
+			// a bare key is a boolean true in structured fields
+			value += ", i"
+		}
+		r.Header.Set("Priority", value)
+	}
+}
+
+// DeadlinePropagation limits the request to the fraction of the time left until the deadline of the request context,
+// so one call of a chain doesn't consume the whole time budget: with 0.5 and 2s left, the request gets 1s.
+// The deadline is computed when the request is sent, it has no effect if the context has no deadline.
+// A fraction out of (0, 1] results in request.ErrInvalidFraction.
+func DeadlinePropagation(fraction float64) request.RequestOption {
+	return func(r *request.Request) {
+		if !(fraction > 0 && fraction <= 1) {
+			r.Err = fmt.Errorf("%w: %v, must be in (0, 1]", request.ErrInvalidFraction, fraction)
+			return
+		}
+		r.DeadlineFraction = fraction
+	}
+}
+
+// IdleTimeout aborts reading the response body with ErrIdleTimeout of the client, if no bytes arrive for the duration,
+// unlike a total deadline, it lets a healthy stream, e.g. SSE, run on, but kills a stalled one.
+// The timeout of the client still limits the whole request.
+func IdleTimeout(d time.Duration) request.RequestOption {
+	return func(r *request.Request) {
+		r.IdleTimeout = d
+	}
+}
+
+// ContentType sets the Content-Type header.
+// The body encoders will not override it.
+func ContentType(mime string) request.RequestOption {
+	return func(r *request.Request) {
+		r.Header.Set("Content-Type", mime)
+	}
+}
+
+// Host sets the `Host` header independently of the URL, e.g. to request a virtual host of a server by its IP address.
+// Setting the header with Header has no effect, net/http takes the host from the URL.
+// For HTTPS, the TLS server name is still taken from the URL.
+func Host(host string) request.RequestOption {
+	return func(r *request.Request) {
+		r.Host = host
+	}
+}
+
+// AbsoluteURI sends the absolute URI of the request in the request line: `GET http://example.com/path HTTP/1.1`,
+// instead of the path only. It is needed when the server is a forward proxy or a gateway that expects the absolute-form.
+// Requests to an http:// URL through the proxy of the transport are already sent so,
+// while HTTPS requests through a proxy use CONNECT and are not affected.
+func AbsoluteURI() request.RequestOption {
+	return func(r *request.Request) {
+		r.AbsoluteURI = true
+	}
+}
+
+// ContentLength overrides the length of the request body, that is sent with the `Content-Length` header,
+// e.g. if the body is wrapped with a transformation that changes its length.
+// The body must have exactly this length, a mismatch breaks the request, it is the caller's responsibility.
+// A zero length with a non-empty body is treated by net/http as unknown and the body is sent chunked.
+func ContentLength(n int64) request.RequestOption {
+	return Hook(func(req *http.Request) error {
+		req.ContentLength = n
+		return nil
+	})
+}
+
+// TransferEncoding sets the transfer encodings of the request, see http.Request.TransferEncoding.
+// TransferEncoding("chunked") sends the body chunked even if its length is known, e.g. for servers that require it.
+// net/http supports only "chunked", other encodings are not sent.
+func TransferEncoding(encodings ...string) request.RequestOption {
+	return func(r *request.Request) {
+		r.TransferEncoding = encodings
+	}
+}
+
+// AddTrailer adds a trailer, that is sent after the body, so the request is sent chunked. It has no effect without a body.
+// A value computed from the body, e.g. a checksum, can be set by a Hook, that wraps http.Request.Body
+// and updates http.Request.Trailer before the body ends.
+func AddTrailer(key, value string) request.RequestOption {
+	return func(r *request.Request) {
+		if r.Trailer == nil {
+			r.Trailer = make(http.Header)
+		}
+		r.Trailer.Add(key, value)
+	}
+}
+
+// Referer sets the Referer header. The value must be an absolute URL, its fragment and user info are removed.
+func Referer(referer string) request.RequestOption {
+	return func(r *request.Request) {
+		u, err := parseAbsoluteURL(referer)
+		if err != nil {
+			r.Err = err
+			return
+		}
+		u.User = nil
+		u.Fragment = ""
+		u.RawFragment = ""
+		r.Header.Set("Referer", u.String())
+	}
+}
+
+// Origin sets the Origin header. The origin is derived from an absolute URL: only the scheme and the host are kept,
+// so a full page URL can be passed as is.
+func Origin(origin string) request.RequestOption {
+	return func(r *request.Request) {
+		u, err := parseAbsoluteURL(origin)
+		if err != nil {
+			r.Err = err
+			return
+		}
+		r.Header.Set("Origin", u.Scheme+"://"+u.Host)
+	}
+}
+
+// parseAbsoluteURL parses the URL and checks that it has a scheme and a host
+func parseAbsoluteURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("%w: %q", request.ErrNotAbsoluteURL, rawURL)
+	}
+	return u, nil
+}
+
+// Range sets the `Range` header to request the bytes from start to end, inclusive.
+// If end is negative, the range is open-ended: from start to the end of the content.
+// If start is negative, the last -start bytes are requested and end is ignored.
+// A server that supports ranges responds with 206 Partial Content, see Response.ContentRange.
+func Range(start, end int64) request.RequestOption {
+	var value string
+	switch {
+	case start < 0:
+		value = fmt.Sprintf("bytes=%d", start)
+	case end < 0:
+		value = fmt.Sprintf("bytes=%d-", start)
+	default:
+		value = fmt.Sprintf("bytes=%d-%d", start, end)
+	}
+	return func(r *request.Request) {
+		r.Header.Set("Range", value)
+	}
+}
+
+// DeleteHeader removes the HTTP header from the request,
+// including the one inherited from the client
+func DeleteHeader(key string) request.RequestOption {
+	return func(r *request.Request) {
+		r.Header.Del(key)
+		r.OmitHeaders = append(r.OmitHeaders, http.CanonicalHeaderKey(key))
+	}
+}
+
 // Headers sets the HTTP header
 func Headers(header http.Header) request.RequestOption {
 	return func(r *request.Request) {
@@ -28,13 +241,69 @@ func Headers(header http.Header) request.RequestOption {
 	}
 }
 
-// AddParam adds a query parameter
+// AddParam adds a query parameter.
+// Multiple values of the same key are sent as repeated parameters: `ids=1&ids=2`, see AddParamCSV for the other style.
 func AddParam(key, value string) request.RequestOption {
 	return func(r *request.Request) {
 		r.Params.Add(key, value)
 	}
 }
 
+// AddFlagParam adds a query parameter without a value, that is rendered as a bare key: `?flag`,
+// while AddParam(key, "") renders `?flag=`. Flags follow other query parameters.
+func AddFlagParam(key string) request.RequestOption {
+	return func(r *request.Request) {
+		r.Flags = append(r.Flags, key)
+	}
+}
+
+// AddParamCSV adds a query parameter with the values joined by commas: `ids=1,2,3`.
+// Like any other value, the joined one is URL-encoded, so commas are sent as `%2C` and are decoded back by the server.
+// Commas within the values can't be told apart from separators, so the values must not contain them.
+func AddParamCSV(key string, values ...string) request.RequestOption {
+	return func(r *request.Request) {
+		r.Params.Add(key, strings.Join(values, ","))
+	}
+}
+
+// AddParamBracketed adds query parameters with bracketed keys: `filter[status]=active&filter[type]=x`,
+// as in the filter convention of JSON:API. Brackets are URL-encoded as `%5B` and `%5D`, like in a form.
+func AddParamBracketed(root string, kv map[string]string) request.RequestOption {
+	return func(r *request.Request) {
+		for key, value := range kv {
+			r.Params.Add(root+"["+key+"]", value)
+		}
+	}
+}
+
+// AddParamsBracketed flattens a struct or a map into query parameters using the bracket notation,
+// e.g. `filter[author][name]=x`. See request.BracketValues for the exact convention.
+func AddParamsBracketed(v any) request.RequestOption {
+	return func(r *request.Request) {
+		params, err := request.BracketValues(v)
+		if err != nil {
+			r.Err = err
+			return
+		}
+		for key, values := range params {
+			r.Params[key] = append(r.Params[key], values...)
+		}
+	}
+}
+
+// JSONParam marshals the value to JSON and sets it as a single query parameter: `filter=%7B%22a%22%3A1%7D`.
+// A marshaling error is returned by IntoHttpRequest.
+func JSONParam(key string, v any) request.RequestOption {
+	return func(r *request.Request) {
+		b, err := json.Marshal(v)
+		if err != nil {
+			r.Err = err
+			return
+		}
+		r.Params.Set(key, string(b))
+	}
+}
+
 // SetParam sets the query parameter
 func SetParam(key, value string) request.RequestOption {
 	return func(r *request.Request) {
@@ -49,6 +318,18 @@ func SetParams(params url.Values) request.RequestOption {
 	}
 }
 
+// RawQuery sets the query string of the URL verbatim, e.g. for pre-signed URLs and signed query strings,
+// which must be sent byte for byte. The query is not sorted or re-escaped, unless query parameters are added
+// with other options, in which case the whole query is encoded again.
+func RawQuery(query string) request.RequestOption {
+	return func(r *request.Request) {
+		if r.URL == nil {
+			return
+		}
+		r.URL.RawQuery = query
+	}
+}
+
 // AddFormField adds a form field
 func AddFormField(key, value string) request.RequestOption {
 	return func(r *request.Request) {
@@ -70,13 +351,64 @@ func SetForm(form url.Values) request.RequestOption {
 	}
 }
 
-// SetBody sets the raw request body
+// SetFormBracketed flattens a struct or a map into form fields using the bracket notation
+// (`items[0][name]=x`), which is expected by PHP and Rails backends.
+// See request.BracketValues for the exact convention.
+func SetFormBracketed(v any) request.RequestOption {
+	return func(r *request.Request) {
+		form, err := request.BracketValues(v)
+		if err != nil {
+			r.Err = err
+			return
+		}
+		for key, values := range form {
+			r.Form[key] = append(r.Form[key], values...)
+		}
+	}
+}
+
+// SetBody sets the raw request body.
+// If the request has no Content-Type header, it will be sent as application/octet-stream.
 func SetBody(body []byte) request.RequestOption {
 	return func(r *request.Request) {
 		r.Body = body
 	}
 }
 
+// SetBodyWithType sets the raw request body along with its content type
+func SetBodyWithType(body []byte, contentType string) request.RequestOption {
+	return func(r *request.Request) {
+		r.Body = body
+		r.Header.Set("Content-Type", contentType)
+	}
+}
+
+// SetBodyProvider sets a function that produces the raw request body.
+// The function is called for every attempt to send the request, so unlike a one-shot io.Reader,
+// a streamed body can be sent again on retries, redirects and authentication challenges.
+// If the request has no Content-Type header, it will be sent as application/octet-stream.
+func SetBodyProvider(provider func() (io.Reader, error)) request.RequestOption {
+	return func(r *request.Request) {
+		r.BodyProvider = provider
+	}
+}
+
+// SetChunkedBody sets a raw request body of unknown length, e.g. a relayed stream.
+// The body is sent with chunked transfer encoding and without Content-Length header.
+// It is read only once, so use SetBodyProvider if the request may be resent.
+// If the request has no Content-Type header, it will be sent as application/octet-stream.
+func SetChunkedBody(body io.Reader) request.RequestOption {
+	return func(r *request.Request) {
+		r.BodyReader = body
+	}
+}
+
+// SetText sets the request body as text/plain.
+// Like SetBody, it has lower priority than files, form data and JSON.
+func SetText(text string) request.RequestOption {
+	return SetBodyWithType([]byte(text), "text/plain; charset=utf-8")
+}
+
 // SetFile sets a file field
 func SetFile(fieldname, source string) request.RequestOption {
 	return func(r *request.Request) {
@@ -98,6 +430,28 @@ func SetFileBody(fieldname, filename string, body any) request.RequestOption {
 	}
 }
 
+// AddFormPart adds a multipart form field with its own content type, e.g. JSON metadata along with a file.
+// The body can be a string, a []byte or an io.Reader. Parts are written before files and form fields.
+func AddFormPart(name, contentType string, body any) request.RequestOption {
+	return func(r *request.Request) {
+		r.Parts = append(r.Parts, &request.FormPart{
+			Name:        name,
+			ContentType: contentType,
+			Body:        body,
+		})
+	}
+}
+
+// MultipartRelated switches the multipart body from `multipart/form-data` to `multipart/related` (RFC 2387),
+// required by some media upload APIs. The `type` parameter refers to the content type of the first part.
+// Parts and files are written without Content-Disposition, files get the `application/octet-stream` type.
+// Form fields are written as `form-data` parts, other bodies (JSON, raw) result in request.ErrNotMultipartBody.
+func MultipartRelated() request.RequestOption {
+	return func(r *request.Request) {
+		r.Header.Set("Content-Type", "multipart/related")
+	}
+}
+
 // Trace enables tracing for the request
 func Trace() request.RequestOption {
 	return func(r *request.Request) {
@@ -105,6 +459,15 @@ func Trace() request.RequestOption {
 	}
 }
 
+// OnTrace sets a callback, that is called with the trace information once the response is complete:
+// when its body is closed, which is done by Fetch and JSON, or when the request failed.
+// It is called only if the request is traced, see Trace and apik.WithTrace.
+func OnTrace(callback func(info *request.TraceInfo)) request.RequestOption {
+	return func(r *request.Request) {
+		r.OnTrace = callback
+	}
+}
+
 // AddCookie adds a cookie
 func AddCookie(cookie *http.Cookie) request.RequestOption {
 	return func(r *request.Request) {
@@ -119,9 +482,94 @@ func SetCookies(cookies []*http.Cookie) request.RequestOption {
 	}
 }
 
+// JSONEncoderOptions sets options of the encoder for the JSON entity.
+// By default, HTML characters are escaped and the body is not indented.
+func JSONEncoderOptions(escapeHTML bool, indent string) request.RequestOption {
+	return func(r *request.Request) {
+		r.JSONEncoder = &request.JSONEncoderOptions{EscapeHTML: escapeHTML, Indent: indent}
+	}
+}
+
+// DisableKeepAlive closes the connection after the request, so it is not returned to the pool.
+// The next request to the same host will use a fresh connection.
+func DisableKeepAlive() request.RequestOption {
+	return func(r *request.Request) {
+		r.Close = true
+	}
+}
+
+// DisableCompression stops the transport from requesting gzip and decompressing the response transparently.
+// It is applied with a copy of the client's transport, see ForceAttemptHTTP2 for the cost of it.
+func DisableCompression() request.RequestOption {
+	return func(r *request.Request) {
+		r.DisableCompression = true
+	}
+}
+
+// ForceAttemptHTTP2 forces an attempt of HTTP/2 for the request if enabled is true, or forces HTTP/1.1 if it is false.
+// Per-request transport settings are applied with a copy of the client's transport.
+// Every combination of such settings has its own connection pool, that is shared by requests with the same settings,
+// so connections are not reused between these requests and requests without them.
+func ForceAttemptHTTP2(enabled bool) request.RequestOption {
+	return func(r *request.Request) {
+		r.ForceAttemptHTTP2 = &enabled
+	}
+}
+
+// RedirectPolicy sets the redirect policy for the request, it has the same semantics as http.Client.CheckRedirect:
+// return http.ErrUseLastResponse to stop at the redirect response, or an error to fail the request.
+// The client's http.Client is not modified: the request is sent with its shallow copy, so it is safe for concurrent use.
+func RedirectPolicy(policy func(req *http.Request, via []*http.Request) error) request.RequestOption {
+	return func(r *request.Request) {
+		r.CheckRedirect = policy
+	}
+}
+
+// NoCookies disables the client's cookie jar for the request.
+// Cookies added with AddCookie or SetCookies are still sent.
+func NoCookies() request.RequestOption {
+	return func(r *request.Request) {
+		r.NoCookies = true
+	}
+}
+
 // SetJSON sets the URL of the request
 func SetJSON(entity any) request.RequestOption {
 	return func(r *request.Request) {
 		r.JSON = entity
 	}
 }
+
+// Label sets a label of the request, that is passed to logging and metrics of the client, but is not sent.
+// Labels like `operation=getUser` identify the request without the high cardinality of the raw path.
+func Label(key, value string) request.RequestOption {
+	return func(r *request.Request) {
+		if r.Labels == nil {
+			r.Labels = make(map[string]string)
+		}
+		r.Labels[key] = value
+	}
+}
+
+// RequireHeaders makes the client return an error naming the missing headers, if the response lacks any of them,
+// e.g. as a lightweight contract check. A header with an empty value is present.
+func RequireHeaders(keys ...string) request.RequestOption {
+	return func(r *request.Request) {
+		r.RequiredHeaders = append(r.RequiredHeaders, keys...)
+	}
+}
+
+// Hook adds a hook that is called with the built http.Request before it is sent
+func Hook(hook func(req *http.Request) error) request.RequestOption {
+	return func(r *request.Request) {
+		r.Hooks = append(r.Hooks, hook)
+	}
+}
+
+// AttemptHook adds a hook that is called with the built http.Request before it is sent,
+// and again before every retry, e.g. to refresh a signature that must not be replayed
+func AttemptHook(hook func(req *http.Request) error) request.RequestOption {
+	return func(r *request.Request) {
+		r.AttemptHooks = append(r.AttemptHooks, hook)
+	}
+}
@@ -0,0 +1,61 @@
+package request
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// BracketValues flattens a struct or a map into url.Values using the bracket notation,
+// which is understood by PHP and Rails backends.
+//
+// The value is first encoded as JSON, so `json` struct tags are respected. Then:
+//   - top-level keys are used as is: `name=x`
+//   - nested object keys are wrapped in brackets: `user[name]=x`
+//   - array elements are indexed: `items[0]=x`, `items[0][name]=x`
+//   - null values are skipped, booleans and numbers are written in their JSON form
+func BracketValues(v any) (values url.Values, err error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var tree any
+	if err = dec.Decode(&tree); err != nil {
+		return
+	}
+
+	obj, ok := tree.(map[string]any)
+	if !ok {
+		err = fmt.Errorf("%w: %T", ErrUnsupportedBodyType, v)
+		return
+	}
+
+	values = make(url.Values)
+	for key, value := range obj {
+		flattenBracket(values, key, value)
+	}
+	return
+}
+
+func flattenBracket(values url.Values, prefix string, v any) {
+	switch val := v.(type) {
+	case map[string]any:
+		for key, value := range val {
+			flattenBracket(values, prefix+"["+key+"]", value)
+		}
+	case []any:
+		for i, value := range val {
+			flattenBracket(values, prefix+"["+strconv.Itoa(i)+"]", value)
+		}
+	case nil:
+	case string:
+		values.Add(prefix, val)
+	default:
+		values.Add(prefix, fmt.Sprint(val))
+	}
+}
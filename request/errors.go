@@ -2,4 +2,11 @@ package request
 
 import "errors"
 
-var ErrUnsupportedBodyType = errors.New("unsupported body type")
+var (
+	ErrUnsupportedBodyType = errors.New("unsupported body type")
+	ErrNotAbsoluteURL      = errors.New("URL is not absolute")
+	ErrInvalidLanguageTag  = errors.New("invalid language tag")
+	ErrInvalidPriority     = errors.New("invalid priority urgency")
+	ErrInvalidFraction     = errors.New("invalid deadline fraction")
+	ErrNotMultipartBody    = errors.New("body can't be sent as multipart/related")
+)
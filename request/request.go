@@ -6,12 +6,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
+	"mime"
 	"mime/multipart"
 	"net/http"
+	"net/textproto"
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
 // FileField represents a file field data for one file
@@ -28,71 +34,251 @@ type FileField struct {
 
 // Write writes the file field to the multipart writer
 func (f *FileField) Write(w *multipart.Writer) (err error) {
+	return f.write(w, false)
+}
+
+// write writes the file field to the multipart writer.
+// A part of multipart/related has no Content-Disposition, only the Content-Type.
+func (f *FileField) write(w *multipart.Writer, related bool) (err error) {
 
+	filename, content := f.Filename, f.Body
 	if f.Source != "" {
-		body, err := os.ReadFile(f.Source)
-		if err != nil {
-			return err
+		if content, err = os.ReadFile(f.Source); err != nil {
+			return
 		}
-		f.Body = body
+		filename = filepath.Base(f.Source)
+	}
 
-		f.Filename = filepath.Base(f.Source)
+	var part io.Writer
+	if related {
+		part, err = w.CreatePart(textproto.MIMEHeader{"Content-Type": {"application/octet-stream"}})
+	} else {
+		part, err = w.CreateFormFile(f.Fieldname, filename)
+	}
+	if err != nil {
+		return
 	}
+	return writeContent(part, content)
+}
+
+// FormPart represents a multipart form field with its own content type, e.g. JSON metadata
+type FormPart struct {
+	// Name is the name of the field
+	Name string
+	// ContentType is the content type of the part
+	ContentType string
+	// Header represents additional headers of the part, e.g. Content-ID
+	Header textproto.MIMEHeader
+	// Body is the content of the part: a string, a []byte or an io.Reader
+	Body any
+}
 
-	part, err := w.CreateFormFile(f.Fieldname, f.Filename)
+// Write writes the form part to the multipart writer
+func (p *FormPart) Write(w *multipart.Writer) (err error) {
+	return p.write(w, false)
+}
+
+// write writes the form part to the multipart writer.
+// A part of multipart/related has no Content-Disposition.
+func (p *FormPart) write(w *multipart.Writer, related bool) (err error) {
+	header := make(textproto.MIMEHeader)
+	for key, values := range p.Header {
+		for _, value := range values {
+			header.Add(key, value)
+		}
+	}
+	if !related {
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"`, quoteEscaper.Replace(p.Name)))
+	}
+	if p.ContentType != "" {
+		header.Set("Content-Type", p.ContentType)
+	}
+	part, err := w.CreatePart(header)
 	if err != nil {
 		return
 	}
-	switch v := f.Body.(type) {
+	return writeContent(part, p.Body)
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// writeContent writes the content of a multipart part
+func writeContent(w io.Writer, content any) (err error) {
+	switch v := content.(type) {
 	case string:
-		_, err = part.Write([]byte(v))
+		_, err = io.WriteString(w, v)
 	case []byte:
-		_, err = part.Write(v)
+		_, err = w.Write(v)
 	case io.Reader:
-		_, err = io.Copy(part, v)
+		_, err = io.Copy(w, v)
 	default:
-		err = fmt.Errorf("%w: %T", ErrUnsupportedBodyType, f.Body)
+		err = fmt.Errorf("%w: %T", ErrUnsupportedBodyType, content)
 	}
 	return
 }
 
+// JSONEncoderOptions represents options of the json.Encoder
+type JSONEncoderOptions struct {
+	// EscapeHTML specifies whether `<`, `>` and `&` are escaped in JSON strings
+	EscapeHTML bool
+	// Indent is the indentation of the JSON body. Empty means no indentation
+	Indent string
+}
+
+// JSONMarshaler encodes a value into JSON.
+// It allows to use a JSON library other than encoding/json.
+type JSONMarshaler interface {
+	Marshal(v any) ([]byte, error)
+}
+
 // Request represents a  wrapper around http.Request
 type Request struct {
 	// Ctx is the context of the request
 	Ctx context.Context
+	// DeadlineFraction limits the request, including retries, to the fraction of the time left until the deadline
+	// of Ctx, if Ctx has one. Zero means no limit
+	DeadlineFraction float64
+	// IdleTimeout aborts reading the response body, if no bytes arrive for the duration. Zero means no limit
+	IdleTimeout time.Duration
 	// Method is the HTTP method. Default is GET
 	Method string
 	// Header is the HTTP headers
 	Header http.Header
+	// OmitHeaders is the list of client headers that must not be sent with the request
+	OmitHeaders []string
 	// Body is the raw request body
 	Body []byte
+	// BodyProvider produces a fresh raw request body for every attempt to send the request.
+	// It takes precedence over Body and makes streamed bodies replayable.
+	BodyProvider func() (io.Reader, error)
+	// BodyReader is a one-shot raw request body of unknown length, it is always sent with chunked transfer encoding.
+	// It can be read only once, so the request is not resent on retries and redirects that require the body.
+	BodyReader io.Reader
 	// Form is the form data that will be encoded as application/x-www-form-urlencoded
 	Form url.Values
-	// Params is the query parameters
+	// Params is the query parameters. They are merged into the query of the URL, replacing values of the same keys
 	Params url.Values
+	// Flags are query parameters without a value, rendered as bare keys after Params: `?k=v&flag`
+	Flags []string
 	// Files represents the files that will be sent in the request's body as multipart/form-data
 	Files []*FileField
+	// Parts represents typed form fields that will be sent in the request's body as multipart/form-data
+	Parts []*FormPart
 	// Cookies is the cookies that will be sent in the request
 	Cookies []*http.Cookie
+	// NoCookies disables the client's cookie jar for the request:
+	// jar cookies are not sent and received cookies are not stored. Cookies is still sent.
+	NoCookies bool
 	// URL is the URL of the request
 	URL *url.URL
+	// Host overrides the host of the URL in the `Host` header, the connection is still made to the URL host
+	Host string
+	// AbsoluteURI makes the request line carry the absolute URI of the request (absolute-form), instead of its path
+	AbsoluteURI bool
+	// TransferEncoding sets http.Request.TransferEncoding. With "chunked" the body is sent chunked,
+	// even if its length is known
+	TransferEncoding []string
+	// Trailer is the trailer sent after the body. A request with a trailer and a body is sent chunked
+	Trailer http.Header
 	// Trace is a flag that indicates if the request should be traced
 	Trace bool
+	// OnTrace is called with the trace information once the response is complete, if the request is traced
+	OnTrace func(info *TraceInfo)
+	// CheckRedirect overrides the redirect policy of the http.Client for the request, see http.Client.CheckRedirect
+	CheckRedirect func(req *http.Request, via []*http.Request) error
+	// Close indicates to close the connection after the request, so it is not reused for other requests
+	Close bool
+	// DisableCompression disables transparent gzip compression of the transport for the request
+	DisableCompression bool
+	// ForceAttemptHTTP2 overrides the HTTP/2 setting of the transport for the request, if set.
+	// False disables HTTP/2.
+	ForceAttemptHTTP2 *bool
 	// JSON is a entity to be sent as JSON
-	JSON      any
-	traceInfo *TraceInfo
+	JSON any
+	// JSONEncoder represents options of the encoder for the JSON entity. Nil means defaults of encoding/json
+	JSONEncoder *JSONEncoderOptions
+	// JSONMarshaler encodes the JSON entity instead of encoding/json. JSONEncoder is ignored if it is set
+	JSONMarshaler JSONMarshaler
+	// Hooks are called with the built http.Request right before it is returned from IntoHttpRequest.
+	// They can be used to sign or otherwise modify the final request.
+	Hooks []func(req *http.Request) error
+	// AttemptHooks are called after Hooks, and again with the copy of the http.Request for every retry attempt,
+	// e.g. to sign it with a fresh timestamp
+	AttemptHooks []func(req *http.Request) error
+	// RequiredHeaders are the headers the response must have, otherwise the client returns an error
+	RequiredHeaders []string
+	// Labels describe the request for logging and metrics, e.g. `operation=getUser`. They are not sent.
+	Labels map[string]string
+	// Err is an error that occurred while applying request options.
+	// If set, it is returned by IntoHttpRequest.
+	Err    error
+	traces *traceStore
+}
+
+// traceStore keeps the trace information of the last sent request. It is shared between clones of the request.
+type traceStore struct {
+	info atomic.Pointer[TraceInfo]
 }
 
 // TraceInfo represents the trace information of the request. Available only if the request is traced.
+// If the request was sent more than once, it represents the last one.
+// A Request created without NewRequest doesn't keep it, use OnTrace to get it.
 func (r *Request) TraceInfo() *TraceInfo {
-	return r.traceInfo
+	if r.traces == nil {
+		return nil
+	}
+	return r.traces.info.Load()
 }
 
-func (r *Request) writeMultiPartFormData() (body io.Reader, err error) {
+// Clone returns a copy of the request, that can be modified without affecting the original one.
+// Header, URL, query parameters, form and slices are copied, while Body, body reader, JSON entity, files content
+// and the context are shared. The trace information is shared with the original request, if it was created
+// with NewRequest, otherwise the clone keeps its own one.
+func (r *Request) Clone() *Request {
+	c := *r
+	if c.traces == nil {
+		// the original is not modified, so it can be cloned concurrently
+		c.traces = new(traceStore)
+	}
+	if r.URL != nil {
+		u := *r.URL
+		if r.URL.User != nil {
+			user := *r.URL.User
+			u.User = &user
+		}
+		c.URL = &u
+	}
+	c.Header = r.Header.Clone()
+	c.Trailer = r.Trailer.Clone()
+	c.Form = url.Values(http.Header(r.Form).Clone())
+	c.Params = url.Values(http.Header(r.Params).Clone())
+	c.OmitHeaders = slices.Clone(r.OmitHeaders)
+	c.Flags = slices.Clone(r.Flags)
+	c.TransferEncoding = slices.Clone(r.TransferEncoding)
+	c.Files = slices.Clone(r.Files)
+	c.Parts = slices.Clone(r.Parts)
+	c.Cookies = slices.Clone(r.Cookies)
+	c.Hooks = slices.Clone(r.Hooks)
+	c.AttemptHooks = slices.Clone(r.AttemptHooks)
+	c.RequiredHeaders = slices.Clone(r.RequiredHeaders)
+	c.Labels = maps.Clone(r.Labels)
+	return &c
+}
+
+func (r *Request) writeMultiPartFormData(header http.Header) (body io.Reader, err error) {
 	buf := new(bytes.Buffer)
 	writer := multipart.NewWriter(buf)
+
+	mediaType, params, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	related := mediaType == "multipart/related"
+
+	for _, part := range r.Parts {
+		if err = part.write(writer, related); err != nil {
+			return
+		}
+	}
 	for _, file := range r.Files {
-		if err = file.Write(writer); err != nil {
+		if err = file.write(writer, related); err != nil {
 			return
 		}
 	}
@@ -110,67 +296,237 @@ func (r *Request) writeMultiPartFormData() (body io.Reader, err error) {
 	if err != nil {
 		return
 	}
-	body = buf
-	r.Header.Set("Content-Type", writer.FormDataContentType())
+	body = bytes.NewReader(buf.Bytes())
+
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		header.Set("Content-Type", writer.FormDataContentType())
+		return
+	}
+	// a user-defined multipart subtype is kept, but the boundary always comes from the writer
+	params["boundary"] = writer.Boundary()
+	if rootType := r.rootPartType(); related && params["type"] == "" && rootType != "" {
+		params["type"] = rootType
+	}
+	header.Set("Content-Type", mime.FormatMediaType(mediaType, params))
 	return
 }
 
-func (r *Request) writeJSON() (body io.Reader, err error) {
+// rootPartType returns the content type of the first part of multipart/related body
+func (r *Request) rootPartType() string {
+	if len(r.Parts) > 0 {
+		return r.Parts[0].ContentType
+	}
+	if len(r.Files) > 0 {
+		return "application/octet-stream"
+	}
+	return "text/plain"
+}
+
+func (r *Request) writeJSON(header http.Header) (body io.Reader, err error) {
+	if r.JSONMarshaler != nil {
+		var data []byte
+		if data, err = r.JSONMarshaler.Marshal(r.JSON); err != nil {
+			return
+		}
+		body = bytes.NewReader(data)
+		setDefaultContentType(header, "application/json")
+		return
+	}
+
 	buf := new(bytes.Buffer)
-	err = json.NewEncoder(buf).Encode(r.JSON)
+	enc := json.NewEncoder(buf)
+	if opts := r.JSONEncoder; opts != nil {
+		enc.SetEscapeHTML(opts.EscapeHTML)
+		enc.SetIndent("", opts.Indent)
+	}
+	err = enc.Encode(r.JSON)
 	if err != nil {
 		return
 	}
-	body = buf
-	r.Header.Set("Content-Type", "application/json")
+	body = bytes.NewReader(buf.Bytes())
+	setDefaultContentType(header, "application/json")
 	return
 }
 
-func (r *Request) writeForm() (body io.Reader) {
-	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+func (r *Request) writeForm(header http.Header) (body io.Reader) {
+	setDefaultContentType(header, "application/x-www-form-urlencoded")
 	return strings.NewReader(r.Form.Encode())
 }
 
-// IntoHttpRequest converts the request to http.Request
-func (r *Request) IntoHttpRequest() (req *http.Request, err error) {
+func (r *Request) writeBody(header http.Header) (body io.Reader) {
+	setDefaultContentType(header, "application/octet-stream")
+	return bytes.NewReader(r.Body)
+}
 
-	if len(r.Params) > 0 {
-		r.URL.RawQuery = r.Params.Encode()
+func (r *Request) provideBody(header http.Header) (body io.Reader, err error) {
+	if body, err = r.BodyProvider(); err != nil {
+		return
 	}
+	setDefaultContentType(header, "application/octet-stream")
+	return
+}
 
-	var body io.Reader
+// readBody returns the body reader wrapped, so http.NewRequest can't determine its length
+func (r *Request) readBody(header http.Header) (body io.Reader) {
+	setDefaultContentType(header, "application/octet-stream")
+	return struct{ io.Reader }{r.BodyReader}
+}
 
-	if len(r.Files) > 0 {
-		body, err = r.writeMultiPartFormData()
+// setDefaultContentType sets the Content-Type header only if it was not set by the user
+func setDefaultContentType(header http.Header, contentType string) {
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", contentType)
+	}
+}
+
+// renderBody encodes the request body according to its type and sets the Content-Type header.
+// Encoded bodies are returned as *bytes.Reader or *strings.Reader,
+// so http.NewRequest sets Content-Length and GetBody for them.
+// With the multipart/related content type, form fields are written as parts,
+// other bodies can't be split into parts and result in ErrNotMultipartBody.
+func (r *Request) renderBody(header http.Header) (body io.Reader, err error) {
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	related := mediaType == "multipart/related"
+	if len(r.Files) > 0 || len(r.Parts) > 0 || (related && len(r.Form) > 0) {
+		body, err = r.writeMultiPartFormData(header)
+	} else if related && (r.JSON != nil || r.BodyProvider != nil || r.BodyReader != nil || len(r.Body) > 0) {
+		err = ErrNotMultipartBody
 	} else if len(r.Form) > 0 {
-		body = r.writeForm()
+		body = r.writeForm(header)
 	} else if r.JSON != nil {
-		body, err = r.writeJSON()
+		body, err = r.writeJSON(header)
+	} else if r.BodyProvider != nil {
+		body, err = r.provideBody(header)
+	} else if r.BodyReader != nil {
+		body = r.readBody(header)
 	} else if len(r.Body) > 0 {
-		body = bytes.NewReader(r.Body)
+		body = r.writeBody(header)
 	}
+	return
+}
 
-	if err != nil {
+// RenderBody returns the encoded request body and its content type without sending the request.
+// It does not modify the request. Note that a multipart body gets a new boundary on every render.
+func (r *Request) RenderBody() (body []byte, contentType string, err error) {
+	header := r.requestHeader()
+
+	var reader io.Reader
+	if reader, err = r.renderBody(header); err != nil || reader == nil {
+		return
+	}
+	if body, err = io.ReadAll(reader); err != nil {
+		return
+	}
+	contentType = header.Get("Content-Type")
+	return
+}
+
+// requestHeader returns a copy of the request header, that can be completed while building http.Request
+func (r *Request) requestHeader() http.Header {
+	header := r.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return header
+}
+
+// IntoHttpRequest converts the request to http.Request.
+// The request itself is not modified, except for its trace information, so it can be sent again.
+func (r *Request) IntoHttpRequest() (req *http.Request, err error) {
+
+	if r.Err != nil {
+		err = r.Err
 		return
 	}
 
-	req, err = http.NewRequestWithContext(r.Ctx, r.Method, r.URL.String(), body)
+	u := *r.URL
+	if len(r.Params) > 0 {
+		// params are merged into the query of the URL, replacing the values of the same keys
+		query := u.Query()
+		for key, values := range r.Params {
+			query[key] = values
+		}
+		u.RawQuery = query.Encode()
+	}
+	for _, flag := range r.Flags {
+		if u.RawQuery != "" {
+			u.RawQuery += "&"
+		}
+		u.RawQuery += url.QueryEscape(flag)
+	}
+
+	header := r.requestHeader()
+	var body io.Reader
+	if body, err = r.renderBody(header); err != nil {
+		return
+	}
+
+	req, err = http.NewRequestWithContext(r.Ctx, r.Method, u.String(), body)
 	if err != nil {
 		return
 	}
 
+	if r.AbsoluteURI && req.URL.Opaque == "" {
+		// an opaque URL starting with `//` is written in the request line as `scheme://host/path`
+		req.URL.Opaque = "//" + req.URL.Host + req.URL.EscapedPath()
+	}
+
+	if r.BodyProvider != nil && body != nil {
+		// every resend of the request, e.g. on retry or redirect, gets a fresh body
+		req.GetBody = func() (io.ReadCloser, error) {
+			b, err := r.BodyProvider()
+			if err != nil {
+				return nil, err
+			}
+			return io.NopCloser(b), nil
+		}
+	}
+
+	if r.BodyReader != nil && body != nil {
+		req.ContentLength = -1
+	}
+	if len(r.TransferEncoding) > 0 {
+		req.TransferEncoding = slices.Clone(r.TransferEncoding)
+		// a known length is sent with Content-Length and conflicts with chunked encoding
+		if r.TransferEncoding[0] == "chunked" && body != nil {
+			req.ContentLength = -1
+		}
+	}
+	if len(r.Trailer) > 0 && body != nil {
+		req.Trailer = r.Trailer.Clone()
+		// trailers are sent only with chunked encoding
+		req.ContentLength = -1
+	}
+
 	if r.Trace {
-		info, ctx := createTraceContext(req.Context())
-		r.traceInfo = info
+		info, ctx := createTraceContext(req.Context(), req.URL)
+		if r.traces != nil {
+			r.traces.info.Store(info)
+		}
 		req = req.WithContext(ctx)
 	}
 
-	req.Header = r.Header
+	req.Header = header
+	req.Close = r.Close
+	if r.Host != "" {
+		req.Host = r.Host
+	}
 
 	for _, cookie := range r.Cookies {
 		req.AddCookie(cookie)
 
 	}
+
+	for _, hook := range r.Hooks {
+		if err = hook(req); err != nil {
+			return
+		}
+	}
+	for _, hook := range r.AttemptHooks {
+		if err = hook(req); err != nil {
+			return
+		}
+	}
 	return
 }
 
@@ -185,6 +541,7 @@ func NewRequest(ctx context.Context, dstURL string, opts ...RequestOption) *Requ
 		Header: make(http.Header),
 		Form:   make(url.Values),
 		Params: make(url.Values),
+		traces: new(traceStore),
 	}
 
 	for _, opt := range opts {
@@ -3,7 +3,10 @@ package request
 import (
 	"context"
 	"crypto/tls"
+	"net"
 	"net/http/httptrace"
+	"net/url"
+	"strings"
 	"time"
 )
 
@@ -49,6 +52,46 @@ type TraceInfo struct {
 	PutIdleError error
 	ConnectStart []TraceConnect
 	ConnectDone  []TraceConnect
+	// target is the host:port of the request URL
+	target string
+}
+
+// ConnectionReused reports whether the request was sent over a connection from the pool
+func (s *TraceInfo) ConnectionReused() bool {
+	return s.GotConn.Reused
+}
+
+// WasIdle reports whether the connection was idle in the pool before it was used for the request
+func (s *TraceInfo) WasIdle() bool {
+	return s.GotConn.WasIdle
+}
+
+// TraceSummary represents aggregated connection stats of the request
+type TraceSummary struct {
+	// ConnectAttempts is the number of started dials, e.g. one per address tried by the dialer
+	ConnectAttempts int
+	// FailedConnects is the number of dials that ended with an error
+	FailedConnects int
+	// Address is the address of the last successful dial, empty if the connection was reused
+	Address string
+	// Proxy reports whether the connection was made to a proxy instead of the target host
+	Proxy bool
+}
+
+// Summary aggregates ConnectStart and ConnectDone into a TraceSummary.
+// A proxy is detected by the address of the requested connection, which is the proxy address
+// instead of the target host, so it's reported even if the connection was reused.
+func (s *TraceInfo) Summary() TraceSummary {
+	summary := TraceSummary{ConnectAttempts: len(s.ConnectStart)}
+	for _, done := range s.ConnectDone {
+		if done.Error != nil {
+			summary.FailedConnects++
+			continue
+		}
+		summary.Address = done.Address
+	}
+	summary.Proxy = s.GetConnHost != "" && s.target != "" && s.GetConnHost != s.target
+	return summary
 }
 
 // Hooks returns a httptrace.ClientTrace with the trace hooks
@@ -119,10 +162,24 @@ func (s *TraceInfo) hooks() *httptrace.ClientTrace {
 	return t
 }
 
-// createTraceContext creates a new context with a trace hook
-func createTraceContext(ctx context.Context) (info *TraceInfo, traceCtx context.Context) {
+// createTraceContext creates a new context with a trace hook for the request to the URL
+func createTraceContext(ctx context.Context, u *url.URL) (info *TraceInfo, traceCtx context.Context) {
 
-	info = &TraceInfo{}
+	info = &TraceInfo{target: targetAddr(u)}
 	traceCtx = httptrace.WithClientTrace(ctx, info.hooks())
 	return
 }
+
+// targetAddr returns the host:port of the URL, with the default port of the scheme, as the transport dials it
+func targetAddr(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		switch u.Scheme {
+		case "https":
+			port = "443"
+		case "http":
+			port = "80"
+		}
+	}
+	return net.JoinHostPort(strings.ToLower(u.Hostname()), port)
+}
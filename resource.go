@@ -0,0 +1,127 @@
+package apik
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/niklak/apik/reqopt"
+	"github.com/niklak/apik/request"
+)
+
+// Resource is a typed REST resource, bound to the client and the base path of the collection, e.g. `/users`.
+// It follows the common conventions, which can be changed with ResourceOption:
+//
+//	Get(id)           GET     /users/{id}
+//	List(params)      GET     /users?params
+//	Create(item)      POST    /users
+//	Update(id, item)  PUT     /users/{id}
+//	Delete(id)        DELETE  /users/{id}
+//
+// Items are sent and received as JSON. Responses with a status code of 400 and above
+// result in ErrUnexpectedStatus, along with the Response.
+type Resource[T any] struct {
+	client *Client
+	path   string
+	config resourceConfig
+}
+
+type resourceConfig struct {
+	updateMethod string
+	itemPath     func(base, id string) string
+}
+
+// ResourceOption changes the conventions of the Resource
+type ResourceOption func(*resourceConfig)
+
+// ResourceUpdateMethod sets the method of Update, e.g. http.MethodPatch. Default is PUT.
+func ResourceUpdateMethod(method string) ResourceOption {
+	return func(c *resourceConfig) {
+		c.updateMethod = method
+	}
+}
+
+// ResourceItemPath sets the function that returns the path of an item from the base path and the id.
+// By default, the escaped id is appended to the base path: `/users/{id}`.
+func ResourceItemPath(itemPath func(base, id string) string) ResourceOption {
+	return func(c *resourceConfig) {
+		c.itemPath = itemPath
+	}
+}
+
+// NewResource creates a Resource of the collection at the path. If c is nil, the DefaultClient is used.
+func NewResource[T any](c *Client, path string, opts ...ResourceOption) *Resource[T] {
+	if c == nil {
+		c = DefaultClient()
+	}
+	r := &Resource[T]{
+		client: c,
+		path:   path,
+		config: resourceConfig{
+			updateMethod: http.MethodPut,
+			itemPath: func(base, id string) string {
+				return strings.TrimSuffix(base, "/") + "/" + url.PathEscape(id)
+			},
+		},
+	}
+	for _, opt := range opts {
+		opt(&r.config)
+	}
+	return r
+}
+
+// Get returns the item with the id
+func (r *Resource[T]) Get(ctx context.Context, id string, opts ...request.RequestOption) (item T, resp *Response, err error) {
+	return decodeResource[T](r.client, request.NewRequest(ctx, r.config.itemPath(r.path, id), opts...))
+}
+
+// List returns the items of the collection, filtered with the query parameters
+func (r *Resource[T]) List(ctx context.Context, params url.Values, opts ...request.RequestOption) (items []T, resp *Response, err error) {
+	req := request.NewRequest(ctx, r.path, opts...)
+	for key, values := range params {
+		req.Params[key] = append(req.Params[key], values...)
+	}
+	return decodeResource[[]T](r.client, req)
+}
+
+// Create sends the item to the collection and returns the created one
+func (r *Resource[T]) Create(ctx context.Context, item T, opts ...request.RequestOption) (created T, resp *Response, err error) {
+	opts = append([]request.RequestOption{reqopt.Method(http.MethodPost), reqopt.SetJSON(item)}, opts...)
+	return decodeResource[T](r.client, request.NewRequest(ctx, r.path, opts...))
+}
+
+// Update sends the item with the id and returns the updated one
+func (r *Resource[T]) Update(ctx context.Context, id string, item T, opts ...request.RequestOption) (updated T, resp *Response, err error) {
+	opts = append([]request.RequestOption{reqopt.Method(r.config.updateMethod), reqopt.SetJSON(item)}, opts...)
+	return decodeResource[T](r.client, request.NewRequest(ctx, r.config.itemPath(r.path, id), opts...))
+}
+
+// Delete deletes the item with the id, the response body is ignored
+func (r *Resource[T]) Delete(ctx context.Context, id string, opts ...request.RequestOption) (resp *Response, err error) {
+	opts = append([]request.RequestOption{reqopt.Method(http.MethodDelete)}, opts...)
+	if resp, err = r.client.JSON(request.NewRequest(ctx, r.config.itemPath(r.path, id), opts...), nil); err != nil {
+		return
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		err = fmt.Errorf("%w: %d", ErrUnexpectedStatus, resp.StatusCode)
+	}
+	return
+}
+
+// decodeResource decodes the response into T, error status codes result in ErrUnexpectedStatus
+func decodeResource[T any](c *Client, req *Request) (result T, resp *Response, err error) {
+	var v T
+	resp, err = c.JSON(req, &v)
+	if resp != nil && resp.StatusCode >= http.StatusBadRequest {
+		// a decoding error of the error body is not relevant
+		err = fmt.Errorf("%w: %d", ErrUnexpectedStatus, resp.StatusCode)
+		return
+	}
+	if err != nil {
+		return
+	}
+	result = v
+	return
+}
@@ -0,0 +1,123 @@
+package apik
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testUser struct {
+	ID   int    `json:"id,omitempty"`
+	Name string `json:"name"`
+}
+
+// usersServer is a minimal REST API of users, kept in memory
+func usersServer() *httptest.Server {
+	var mu sync.Mutex
+	users := map[int]testUser{}
+	nextID := 1
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		idStr, hasID := strings.CutPrefix(r.URL.Path, "/users/")
+		id, _ := strconv.Atoi(idStr)
+		_, exists := users[id]
+		if hasID && !exists {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			return
+		}
+
+		var user testUser
+		switch {
+		case r.Method == http.MethodGet && !hasID:
+			list := []testUser{}
+			for i := 1; i < nextID; i++ {
+				if u, ok := users[i]; ok && strings.HasPrefix(u.Name, r.URL.Query().Get("prefix")) {
+					list = append(list, u)
+				}
+			}
+			json.NewEncoder(w).Encode(list)
+		case r.Method == http.MethodGet:
+			json.NewEncoder(w).Encode(users[id])
+		case r.Method == http.MethodPost && !hasID:
+			json.NewDecoder(r.Body).Decode(&user)
+			user.ID = nextID
+			users[nextID] = user
+			nextID++
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(user)
+		case r.Method == http.MethodPatch && hasID:
+			json.NewDecoder(r.Body).Decode(&user)
+			user.ID = id
+			users[id] = user
+			json.NewEncoder(w).Encode(user)
+		case r.Method == http.MethodDelete && hasID:
+			delete(users, id)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+}
+
+func TestResource(t *testing.T) {
+	server := usersServer()
+	defer server.Close()
+
+	ctx := context.Background()
+	users := NewResource[testUser](New(WithBaseUrl(server.URL)), "/users", ResourceUpdateMethod(http.MethodPatch))
+
+	created, resp, err := users.Create(ctx, testUser{Name: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, testUser{ID: 1, Name: "alice"}, created)
+
+	_, _, err = users.Create(ctx, testUser{Name: "bob"})
+	require.NoError(t, err)
+
+	user, _, err := users.Get(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, created, user)
+
+	updated, _, err := users.Update(ctx, "1", testUser{Name: "alicia"})
+	require.NoError(t, err)
+	assert.Equal(t, testUser{ID: 1, Name: "alicia"}, updated)
+
+	list, _, err := users.List(ctx, url.Values{"prefix": {"ali"}})
+	require.NoError(t, err)
+	assert.Equal(t, []testUser{updated}, list)
+
+	resp, err = users.Delete(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	user, resp, err = users.Get(ctx, "1")
+	assert.ErrorIs(t, err, ErrUnexpectedStatus)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Zero(t, user)
+
+	_, err = users.Delete(ctx, "1")
+	assert.ErrorIs(t, err, ErrUnexpectedStatus)
+
+	// the default update method is PUT, which is not allowed by the server
+	_, _, err = NewResource[testUser](New(WithBaseUrl(server.URL)), "/users").Update(ctx, "2", testUser{Name: "robert"})
+	assert.ErrorIs(t, err, ErrUnexpectedStatus)
+
+	items := NewResource[testUser](New(WithBaseUrl(server.URL)), "/users", ResourceItemPath(func(base, id string) string {
+		return base + "/" + strings.TrimPrefix(id, "user-")
+	}))
+	user, _, err = items.Get(ctx, "user-2")
+	require.NoError(t, err)
+	assert.Equal(t, "bob", user.Name)
+}
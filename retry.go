@@ -0,0 +1,191 @@
+package apik
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/niklak/apik/reqopt"
+)
+
+// retryPolicy decides whether and when a failed request is sent again
+type retryPolicy struct {
+	maxAttempts int
+	backoff     func(attempt int) time.Duration
+	budget      time.Duration
+	// nonIdempotent allows retries of POST, PATCH and other non-idempotent requests without an idempotency key
+	nonIdempotent bool
+	// retryIf is an additional condition, that is checked with the buffered response if the built-in one is not met
+	retryIf func(resp *http.Response, attempts int) bool
+}
+
+// canRetry reports whether the request may be sent more than once without duplicate side effects.
+// Idempotent methods can always be retried, other methods only with an idempotency key or if it is allowed by the policy.
+func (p *retryPolicy) canRetry(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return p.nonIdempotent || req.Header.Get(reqopt.IdempotencyKeyHeader) != ""
+}
+
+// shouldRetry reports whether the result of the attempt is worth retrying.
+// The retry condition is checked only if the response body is buffered for it.
+func (p *retryPolicy) shouldRetry(resp *http.Response, attempts int, err error, buffered bool) bool {
+	// the caller gave up, the host is known to be failing or it is denied by the host policy
+	if IsErrorKind(err, KindCanceled) || errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrBlockedHost) {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return buffered && p.retryIf(resp, attempts)
+}
+
+// retryBufferLimit is the maximum size of the response body buffered for the retry condition of WithRetryIf
+const retryBufferLimit = 1 << 20
+
+// bufferForRetry reads the response body into memory, so it can be inspected by the retry condition
+// and still be read by the caller. A body larger than retryBufferLimit is not buffered:
+// it is restored for the caller and false is returned.
+func bufferForRetry(resp *http.Response) (bool, error) {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, retryBufferLimit+1))
+	if err != nil {
+		resp.Body.Close()
+		return false, newRequestError(err)
+	}
+	if len(body) > retryBufferLimit {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return false, nil
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return true, nil
+}
+
+type streamKey struct{}
+
+// doStream is do for responses, that are read as a stream, e.g. by SSE:
+// their bodies are not buffered for the retry condition of WithRetryIf, so it is not checked
+func (c *Client) doStream(req *Request) (resp *http.Response, attempts int, err error) {
+	r := req.Clone()
+	r.Ctx = context.WithValue(req.Ctx, streamKey{}, true)
+	return c.do(r)
+}
+
+// isStream reports whether the response of the request is read as a stream, see doStream
+func isStream(ctx context.Context) bool {
+	stream, _ := ctx.Value(streamKey{}).(bool)
+	return stream
+}
+
+// delay returns the delay before the next attempt
+func (p *retryPolicy) delay(attempt int) time.Duration {
+	if p.backoff == nil {
+		return 0
+	}
+	return p.backoff(attempt)
+}
+
+// do sends the http.Request with the send function until it succeeds or the policy gives up.
+// It returns the result of the last attempt and the number of attempts made.
+func (p *retryPolicy) do(rawReq *http.Request, send func(*http.Request) (*http.Response, error)) (resp *http.Response, attempts int, err error) {
+	start := time.Now()
+	ctx := rawReq.Context()
+	// a body that can't be obtained again can't be retried
+	replayable := rawReq.Body == nil || rawReq.GetBody != nil
+	replayable = replayable && p.canRetry(rawReq)
+
+	req := rawReq
+	for {
+		attempts++
+		resp, err = send(req)
+		buffered := false
+		if err == nil && p.retryIf != nil && attempts < p.maxAttempts && replayable && !isStream(ctx) {
+			if buffered, err = bufferForRetry(resp); err != nil {
+				resp = nil
+			}
+		}
+		if attempts >= p.maxAttempts || !replayable || !p.shouldRetry(resp, attempts, err, buffered) {
+			return
+		}
+
+		delay := p.delay(attempts)
+		if p.budget > 0 && time.Since(start)+delay >= p.budget {
+			return
+		}
+
+		if resp != nil {
+			drainAndClose(resp.Body)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, attempts, newRequestError(ctx.Err())
+		case <-timer.C:
+		}
+
+		if req, err = rewindRequest(rawReq); err != nil {
+			return nil, attempts, err
+		}
+	}
+}
+
+// ExponentialBackoff returns a backoff function for WithRetry, that doubles the delay with every attempt:
+// base, 2*base, 4*base and so on, up to max.
+// If jitter is true, full jitter is applied: the delay is random between 0 and the computed one,
+// so clients that failed at the same time do not retry at the same time.
+// A base of zero or less means no delay.
+func ExponentialBackoff(base, max time.Duration, jitter bool) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		if base <= 0 {
+			return 0
+		}
+		d := max
+		if shift := attempt - 1; shift < 63 {
+			if exp := base << shift; exp > 0 && exp>>shift == base && exp < max {
+				d = exp
+			}
+		}
+		if jitter && d > 0 {
+			d = rand.N(d + 1)
+		}
+		return d
+	}
+}
+
+// attemptHooksKey is the context key of request.Request.AttemptHooks, that are called again by rewindRequest
+type attemptHooksKey struct{}
+
+// rewindRequest returns a copy of the http.Request with a fresh body, so it can be sent again.
+// The attempt hooks of the request are called with the copy.
+func rewindRequest(rawReq *http.Request) (*http.Request, error) {
+	req := rawReq.Clone(rawReq.Context())
+	if rawReq.GetBody != nil {
+		body, err := rawReq.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = body
+	}
+	hooks, _ := rawReq.Context().Value(attemptHooksKey{}).([]func(req *http.Request) error)
+	for _, hook := range hooks {
+		if err := hook(req); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
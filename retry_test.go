@@ -0,0 +1,307 @@
+package apik
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/niklak/apik/reqopt"
+	"github.com/niklak/apik/request"
+)
+
+// flakyServer responds with the status code until the number of hits reaches okAfter, then responds with 200
+func flakyServer(status int, okAfter int32, hits *atomic.Int32, bodies chan<- string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := hits.Add(1)
+		if bodies != nil {
+			body, _ := io.ReadAll(r.Body)
+			bodies <- string(body)
+		}
+		if okAfter > 0 && n >= okAfter {
+			w.Write([]byte("ok"))
+			return
+		}
+		w.WriteHeader(status)
+	}))
+}
+
+func TestClient_Retry(t *testing.T) {
+
+	hits := &atomic.Int32{}
+	bodies := make(chan string, 10)
+	server := flakyServer(http.StatusServiceUnavailable, 3, hits, bodies)
+	defer server.Close()
+
+	client := New(WithBaseUrl(server.URL), WithRetry(5, nil))
+
+	var body string
+	resp, err := client.Fetch(
+		request.NewRequest(context.Background(), "/", reqopt.Method(http.MethodPut), reqopt.SetBody([]byte("payload"))),
+		&body,
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, 3, resp.Attempts)
+	assert.Equal(t, "ok", body)
+
+	close(bodies)
+	for b := range bodies {
+		assert.Equal(t, "payload", b)
+	}
+}
+
+func TestClient_RetryNonIdempotent(t *testing.T) {
+
+	hits := &atomic.Int32{}
+	server := flakyServer(http.StatusServiceUnavailable, 0, hits, nil)
+	defer server.Close()
+
+	post := func(client *Client) *Response {
+		resp, err := client.Fetch(
+			request.NewRequest(context.Background(), "/", reqopt.Method(http.MethodPost), reqopt.SetBody([]byte("payload"))),
+			nil,
+		)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	resp := post(New(WithBaseUrl(server.URL), WithRetry(3, nil)))
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, 1, resp.Attempts)
+
+	resp = post(New(WithBaseUrl(server.URL), WithRetry(3, nil), WithRetryNonIdempotent()))
+	assert.Equal(t, 3, resp.Attempts)
+	assert.Equal(t, int32(4), hits.Load())
+}
+
+func TestClient_RetryMaxAttempts(t *testing.T) {
+
+	hits := &atomic.Int32{}
+	server := flakyServer(http.StatusBadGateway, 0, hits, nil)
+	defer server.Close()
+
+	client := New(WithBaseUrl(server.URL), WithRetry(3, func(attempt int) time.Duration {
+		return time.Millisecond
+	}))
+
+	resp, err := client.Fetch(request.NewRequest(context.Background(), "/"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.Equal(t, 3, resp.Attempts)
+	assert.Equal(t, int32(3), hits.Load())
+
+	// 500 is not retried
+	hits.Store(0)
+	server500 := flakyServer(http.StatusInternalServerError, 0, hits, nil)
+	defer server500.Close()
+
+	resp, err = client.Fetch(request.NewRequest(context.Background(), server500.URL), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, resp.Attempts)
+}
+
+func TestClient_RetryBudget(t *testing.T) {
+
+	hits := &atomic.Int32{}
+	server := flakyServer(http.StatusServiceUnavailable, 0, hits, nil)
+	defer server.Close()
+
+	client := New(
+		WithBaseUrl(server.URL),
+		WithRetry(10, func(attempt int) time.Duration { return 100 * time.Millisecond }),
+		WithRetryBudget(250*time.Millisecond),
+	)
+
+	start := time.Now()
+	resp, err := client.Fetch(request.NewRequest(context.Background(), "/"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, 3, resp.Attempts)
+	assert.Less(t, time.Since(start), 250*time.Millisecond)
+
+	// the request context fires first
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	client = New(
+		WithBaseUrl(server.URL),
+		WithRetry(10, func(attempt int) time.Duration { return time.Second }),
+		WithRetryBudget(time.Minute),
+	)
+
+	_, err = client.Fetch(request.NewRequest(ctx, "/"), nil)
+	assert.True(t, IsErrorKind(err, KindTimeout))
+}
+
+func TestExponentialBackoff(t *testing.T) {
+
+	backoff := ExponentialBackoff(100*time.Millisecond, time.Second, false)
+
+	expected := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}
+	for i, d := range expected {
+		assert.Equal(t, d, backoff(i+1))
+	}
+	assert.Equal(t, time.Second, backoff(100))
+
+	jittered := ExponentialBackoff(100*time.Millisecond, time.Second, true)
+	for attempt := 1; attempt <= 10; attempt++ {
+		d := jittered(attempt)
+		assert.GreaterOrEqual(t, d, time.Duration(0))
+		assert.LessOrEqual(t, d, backoff(attempt))
+	}
+
+	// without the base there is no backoff
+	none := ExponentialBackoff(0, time.Second, true)
+	for attempt := 1; attempt <= 3; attempt++ {
+		assert.Equal(t, time.Duration(0), none(attempt))
+	}
+}
+
+func TestClient_RetryIdempotencyKey(t *testing.T) {
+
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(reqopt.IdempotencyKeyHeader))
+		if len(keys)%2 == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client := New(WithBaseUrl(server.URL), WithRetry(2, nil))
+
+	req := request.NewRequest(context.Background(), "/", reqopt.Method(http.MethodPost), reqopt.IdempotencyKey("key-1"))
+	resp, err := client.Fetch(req, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, resp.Attempts)
+	assert.Equal(t, []string{"key-1", "key-1"}, keys)
+
+	// a new key is generated for every sending, but not for retries
+	keys = nil
+	req = request.NewRequest(context.Background(), "/", reqopt.Method(http.MethodPost), reqopt.AutoIdempotencyKey())
+	_, err = client.Fetch(req, nil)
+	assert.NoError(t, err)
+	_, err = client.Fetch(req, nil)
+	assert.NoError(t, err)
+
+	assert.Len(t, keys, 4)
+	assert.Len(t, keys[0], 36)
+	assert.Equal(t, keys[0], keys[1])
+	assert.Equal(t, keys[2], keys[3])
+	assert.NotEqual(t, keys[0], keys[2])
+}
+
+func TestClient_RetryIf(t *testing.T) {
+
+	hits := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) < 3 {
+			w.Write([]byte(`{"error":"busy"}`))
+			return
+		}
+		w.Write([]byte(`{"result":"done"}`))
+	}))
+	defer server.Close()
+
+	var seenAttempts []int
+	client := New(
+		WithBaseUrl(server.URL),
+		WithRetry(5, nil),
+		WithRetryIf(func(resp *Response, err error) bool {
+			assert.NoError(t, err)
+			seenAttempts = append(seenAttempts, resp.Attempts)
+			body, _ := io.ReadAll(resp.Raw.Body)
+			return strings.Contains(string(body), `"error"`)
+		}),
+	)
+
+	var body string
+	resp, err := client.Fetch(request.NewRequest(context.Background(), "/"), &body)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, resp.Attempts)
+	assert.Equal(t, `{"result":"done"}`, body)
+	assert.Equal(t, []int{1, 2, 3}, seenAttempts)
+
+	// the condition is not checked after the last attempt, the body of it is returned
+	hits.Store(0)
+	seenAttempts = nil
+	client = New(WithBaseUrl(server.URL), WithRetry(2, nil), WithRetryIf(func(resp *Response, err error) bool {
+		seenAttempts = append(seenAttempts, resp.Attempts)
+		return true
+	}))
+	resp, err = client.Fetch(request.NewRequest(context.Background(), "/"), &body)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, resp.Attempts)
+	assert.Equal(t, `{"error":"busy"}`, body)
+	assert.Equal(t, []int{1}, seenAttempts)
+}
+
+func TestClient_RetryIfStream(t *testing.T) {
+
+	hits := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.URL.Path == "/large" {
+			w.Write([]byte(strings.Repeat("x", retryBufferLimit+1)))
+			return
+		}
+		// an endless event stream
+		w.Header().Set("Content-Type", "text/event-stream")
+		for {
+			if _, err := w.Write([]byte("data: tick\n\n")); err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}))
+	defer server.Close()
+
+	checked := &atomic.Int32{}
+	client := New(WithBaseUrl(server.URL), WithRetry(3, nil), WithRetryIf(func(resp *Response, err error) bool {
+		checked.Add(1)
+		return true
+	}))
+
+	// the stream is not buffered for the condition
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	count := 0
+	_, err := client.SSE(request.NewRequest(ctx, "/events"), func(event SSEEvent) error {
+		count++
+		if count == 3 {
+			cancel()
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 3, count)
+
+	// a body over the limit is not buffered, it is returned intact
+	var body string
+	resp, err := client.Fetch(request.NewRequest(context.Background(), "/large"), &body)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, resp.Attempts)
+	assert.Len(t, body, retryBufferLimit+1)
+
+	assert.Equal(t, int32(0), checked.Load())
+	assert.Equal(t, int32(2), hits.Load())
+}
@@ -0,0 +1,83 @@
+package apik
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// slowRequests describes how requests exceeding the threshold are logged, see WithSlowRequestThreshold
+type slowRequests struct {
+	threshold time.Duration
+	level     zerolog.Level
+	message   string
+}
+
+// SlowRequestOption changes how slow requests are logged, see WithSlowRequestThreshold
+type SlowRequestOption func(*slowRequests)
+
+// SlowRequestLevel sets the level of the slow request log entry. Default is zerolog.WarnLevel.
+func SlowRequestLevel(level zerolog.Level) SlowRequestOption {
+	return func(s *slowRequests) {
+		s.level = level
+	}
+}
+
+// SlowRequestMessage sets the message of the slow request log entry. Default is "slow request".
+func SlowRequestMessage(message string) SlowRequestOption {
+	return func(s *slowRequests) {
+		s.message = message
+	}
+}
+
+// WithSlowRequestThreshold logs requests, that take longer than the threshold to get the response headers,
+// including retries. The entry contains the method, the URL, the elapsed time and the number of attempts,
+// and, if the request is traced, the time spent on DNS lookup, connecting, TLS handshake and waiting for the first byte.
+// It is logged with the logger of WithLogger, or with the global zerolog logger.
+func WithSlowRequestThreshold(threshold time.Duration, opts ...SlowRequestOption) ClientOption {
+	return func(c *Client) {
+		s := &slowRequests{threshold: threshold, level: zerolog.WarnLevel, message: "slow request"}
+		for _, opt := range opts {
+			opt(s)
+		}
+		c.slowRequests = s
+	}
+}
+
+// logSlowRequest logs the request if it took longer than the slow request threshold
+func (c *Client) logSlowRequest(req *Request, rawReq *http.Request, attempts int, err error, elapsed time.Duration) {
+	if elapsed <= c.slowRequests.threshold {
+		return
+	}
+	logger := &c.logger
+	if c.requestLogger != nil {
+		logger = c.requestLogger
+	}
+	event := logger.WithLevel(c.slowRequests.level).
+		Str("method", rawReq.Method).
+		Str("url", rawReq.URL.Redacted())
+	logLabels(event, rawReq).
+		Dur("elapsed", elapsed).
+		Int("attempts", attempts)
+	if info := req.TraceInfo(); info != nil {
+		t := info.Timings
+		event.
+			Dur("dns", between(t.DNSStart, t.DNSDone)).
+			Dur("connect", between(t.ConnectStart, t.ConnectDone)).
+			Dur("tls", between(t.TLSHandshakeStart, t.TLSHandshakeDone)).
+			Dur("first_byte", between(t.WroteRequest, t.FirstByte))
+	}
+	if err != nil {
+		event.Err(err)
+	}
+	event.Msg(c.slowRequests.message)
+}
+
+// between returns the time between start and end, or zero if one of them is not set
+func between(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() {
+		return 0
+	}
+	return end.Sub(start)
+}
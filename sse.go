@@ -0,0 +1,115 @@
+package apik
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SSEEvent represents a server-sent event
+type SSEEvent struct {
+	// ID is the last event ID, it is kept between events until the server changes it
+	ID string
+	// Event is the event type. Default is "message"
+	Event string
+	// Data is the event payload. Multiple data lines are joined with "\n"
+	Data string
+	// Retry is the reconnection time, sent by the server. Zero if not set
+	Retry time.Duration
+}
+
+// SSE sends an http.Request built from Request and reads the response body as a `text/event-stream`.
+// Every complete event is passed to the handler, until the stream ends, the handler returns an error,
+// or the request context is cancelled.
+func (c *Client) SSE(req *Request, handler func(event SSEEvent) error) (resp *Response, err error) {
+	if req.Header.Get("Accept") == "" {
+		req = req.Clone()
+		req.Header.Set("Accept", "text/event-stream")
+	}
+
+	var rawResp *http.Response
+	var attempts int
+	if rawResp, attempts, err = c.doStream(req); err != nil {
+		return
+	}
+	defer rawResp.Body.Close()
+	resp = c.newResponse(rawResp, attempts)
+
+	err = readSSE(resp.bodyReader(req.Ctx, rawResp.Body), handler)
+	if ctxErr := req.Ctx.Err(); err != nil && ctxErr != nil {
+		err = ctxErr
+	}
+	return
+}
+
+// readSSE parses the event stream according to the HTML Living Standard
+func readSSE(r io.Reader, handler func(event SSEEvent) error) error {
+	reader := bufio.NewReader(r)
+
+	var (
+		lastID string
+		retry  time.Duration
+		event  string
+		data   strings.Builder
+	)
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if err != nil && line == "" {
+			// an incomplete event at the end of the stream is discarded
+			return nil
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+
+		if line == "" {
+			if data.Len() > 0 {
+				ev := SSEEvent{
+					ID:    lastID,
+					Event: event,
+					Data:  strings.TrimSuffix(data.String(), "\n"),
+					Retry: retry,
+				}
+				if ev.Event == "" {
+					ev.Event = "message"
+				}
+				if err := handler(ev); err != nil {
+					return err
+				}
+			}
+			event = ""
+			data.Reset()
+			continue
+		}
+
+		if strings.HasPrefix(line, ":") {
+			// comment
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				lastID = value
+			}
+		case "retry":
+			if ms, err := strconv.ParseUint(value, 10, 64); err == nil {
+				retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
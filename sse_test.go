@@ -0,0 +1,115 @@
+package apik
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/niklak/apik/request"
+)
+
+func TestClient_SSE(t *testing.T) {
+
+	stream := strings.Join([]string{
+		": comment line",
+		"retry: 3000",
+		"id: 1",
+		"data: first",
+		"",
+		"event: update",
+		"data: line 1",
+		"data:line 2",
+		"",
+		"id: 2",
+		"data: {\"k\":\"v\"}",
+		"",
+		"event: empty",
+		"",
+		"data: incomplete",
+	}, "\r\n")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, stream)
+	}))
+	defer server.Close()
+
+	client := New(WithBaseUrl(server.URL))
+
+	var events []SSEEvent
+	resp, err := client.SSE(
+		request.NewRequest(context.Background(), "/events"),
+		func(event SSEEvent) error {
+			events = append(events, event)
+			return nil
+		},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	expected := []SSEEvent{
+		{ID: "1", Event: "message", Data: "first", Retry: 3 * time.Second},
+		{ID: "1", Event: "update", Data: "line 1\nline 2", Retry: 3 * time.Second},
+		{ID: "2", Event: "message", Data: `{"k":"v"}`, Retry: 3 * time.Second},
+	}
+	assert.Equal(t, expected, events)
+
+	// handler error stops reading
+	errStop := errors.New("stop")
+	count := 0
+	_, err = client.SSE(
+		request.NewRequest(context.Background(), "/events"),
+		func(event SSEEvent) error {
+			count++
+			return errStop
+		},
+	)
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, 1, count)
+}
+
+func TestClient_SSECancel(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for {
+			if _, err := fmt.Fprint(w, "data: tick\n\n"); err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}))
+	defer server.Close()
+
+	client := New(WithBaseUrl(server.URL))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	count := 0
+	_, err := client.SSE(
+		request.NewRequest(ctx, "/events"),
+		func(event SSEEvent) error {
+			count++
+			if count == 3 {
+				cancel()
+			}
+			return nil
+		},
+	)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.GreaterOrEqual(t, count, 3)
+}
@@ -0,0 +1,88 @@
+package apik
+
+import (
+	"crypto/tls"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// transportSettings represents transport options that can be changed for a single request
+type transportSettings struct {
+	disableCompression bool
+	// http2 is 1 to force an attempt of HTTP/2, -1 to disable it and 0 to keep the transport setting
+	http2 int
+}
+
+func requestTransportSettings(req *Request) (settings transportSettings) {
+	settings.disableCompression = req.DisableCompression
+	if req.ForceAttemptHTTP2 != nil {
+		settings.http2 = -1
+		if *req.ForceAttemptHTTP2 {
+			settings.http2 = 1
+		}
+	}
+	return
+}
+
+// transportVariants keeps a copy of the client transport for every combination of per-request settings,
+// so requests with the same settings share a connection pool
+type transportVariants struct {
+	mu       sync.Mutex
+	variants map[transportSettings]http.RoundTripper
+}
+
+// transportFor returns a copy of the transport of the http.Client with the settings applied.
+// Custom http.RoundTripper implementations can not be configured and are returned as is.
+func (c *Client) transportFor(settings transportSettings) http.RoundTripper {
+	c.transports.mu.Lock()
+	defer c.transports.mu.Unlock()
+
+	if rt, ok := c.transports.variants[settings]; ok {
+		return rt
+	}
+
+	rt := configuredTransport(c.c.Transport, settings)
+	if c.transports.variants == nil {
+		c.transports.variants = make(map[transportSettings]http.RoundTripper)
+	}
+	c.transports.variants[settings] = rt
+	return rt
+}
+
+func configuredTransport(rt http.RoundTripper, settings transportSettings) http.RoundTripper {
+	var t *http.Transport
+	switch v := rt.(type) {
+	case nil:
+		t = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		t = v.Clone()
+	case *ntlmTransport:
+		wrapped := *v
+		wrapped.rt = configuredTransport(v.rt, settings)
+		return &wrapped
+	default:
+		log.Warn().Str("module", "apik").Msgf("unable to configure transport of type %T for the request", rt)
+		return rt
+	}
+
+	if settings.disableCompression {
+		t.DisableCompression = true
+	}
+	switch settings.http2 {
+	case 1:
+		t.ForceAttemptHTTP2 = true
+	case -1:
+		t.ForceAttemptHTTP2 = false
+		t.TLSNextProto = make(map[string]func(authority string, c *tls.Conn) http.RoundTripper)
+		if t.TLSClientConfig != nil {
+			// the server must not negotiate HTTP/2 with ALPN
+			t.TLSClientConfig.NextProtos = slices.DeleteFunc(slices.Clone(t.TLSClientConfig.NextProtos), func(proto string) bool {
+				return proto == "h2"
+			})
+		}
+	}
+	return t
+}
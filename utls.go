@@ -0,0 +1,88 @@
+//go:build utls
+
+package apik
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	utls "github.com/refraction-networking/utls"
+)
+
+// WithUTLSFingerprint makes the client send the TLS ClientHello of the given profile, e.g. utls.HelloChrome_Auto,
+// so the TLS fingerprint (JA3) of requests matches the one of a browser.
+//
+// The option is available only with the `utls` build tag:
+//
+//	go build -tags utls
+//
+// The transport speaks HTTP/2 only over *tls.Conn, so the profile advertises only HTTP/1.1 with ALPN,
+// and http.Response.TLS is nil. Server name, root CAs and InsecureSkipVerify are taken
+// from the TLS config of the transport. It works only if the transport is *http.Transport.
+//
+// The fingerprint can't be applied to connections through a proxy, as their TLS handshake is made by the transport.
+// Combined with WithProxy or WithProxyRotation, every request fails with ErrFingerprintProxy,
+// and proxies from the environment (HTTPS_PROXY and so on) are not used.
+func WithUTLSFingerprint(profile utls.ClientHelloID) ClientOption {
+	return func(c *Client) {
+		c.tlsFingerprint = true
+		c.transportOpts = append(c.transportOpts, func(t *http.Transport) {
+			// requests through a proxy would be sent with the fingerprint of crypto/tls
+			t.Proxy = nil
+			defaultDial := (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+			t.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+				// the dialer is read on every dial, as the dialer options, e.g. WithHostPolicy, may be applied later
+				dial := t.DialContext
+				if dial == nil {
+					dial = defaultDial
+				}
+				conn, err := dial(ctx, network, addr)
+				if err != nil {
+					return nil, err
+				}
+				uconn, err := newUTLSConn(conn, addr, t, profile)
+				if err != nil {
+					conn.Close()
+					return nil, err
+				}
+				if err = uconn.HandshakeContext(ctx); err != nil {
+					conn.Close()
+					return nil, err
+				}
+				return uconn, nil
+			}
+			t.ForceAttemptHTTP2 = false
+		})
+	}
+}
+
+// newUTLSConn creates a client connection with the ClientHello of the profile, that advertises only HTTP/1.1
+func newUTLSConn(conn net.Conn, addr string, t *http.Transport, profile utls.ClientHelloID) (*utls.UConn, error) {
+	cfg := &utls.Config{}
+	if t.TLSClientConfig != nil {
+		cfg.ServerName = t.TLSClientConfig.ServerName
+		cfg.RootCAs = t.TLSClientConfig.RootCAs
+		cfg.InsecureSkipVerify = t.TLSClientConfig.InsecureSkipVerify
+	}
+	if cfg.ServerName == "" {
+		cfg.ServerName, _, _ = net.SplitHostPort(addr)
+	}
+
+	spec, err := utls.UTLSIdToSpec(profile)
+	if err != nil {
+		return nil, err
+	}
+	for _, ext := range spec.Extensions {
+		if alpn, ok := ext.(*utls.ALPNExtension); ok {
+			alpn.AlpnProtocols = []string{"http/1.1"}
+		}
+	}
+
+	uconn := utls.UClient(conn, cfg, utls.HelloCustom)
+	if err = uconn.ApplyPreset(&spec); err != nil {
+		return nil, err
+	}
+	return uconn, nil
+}
@@ -0,0 +1,70 @@
+//go:build utls
+
+package apik
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	utls "github.com/refraction-networking/utls"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/niklak/apik/request"
+	"github.com/niklak/httpbulb"
+)
+
+func TestClient_UTLSFingerprint(t *testing.T) {
+	testServer := httptest.NewUnstartedServer(httpbulb.NewRouter())
+	testServer.EnableHTTP2 = true
+	testServer.StartTLS()
+	defer testServer.Close()
+
+	client := New(
+		WithBaseUrl(testServer.URL),
+		WithHttpClient(testServer.Client()),
+		WithUTLSFingerprint(utls.HelloChrome_Auto),
+	)
+
+	resp, err := client.Fetch(request.NewRequest(context.Background(), "/get"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, 1, resp.Raw.ProtoMajor)
+}
+
+func TestClient_UTLSFingerprintHostPolicy(t *testing.T) {
+	testServer := httptest.NewTLSServer(httpbulb.NewRouter())
+	defer testServer.Close()
+
+	// the fingerprint dials through the dialer of the host policy, even if it is passed first
+	client := New(
+		WithUTLSFingerprint(utls.HelloChrome_Auto),
+		WithBaseUrl(testServer.URL),
+		WithHttpClient(testServer.Client()),
+		WithHostPolicy(DenyPrivateAddr),
+	)
+
+	_, err := client.Fetch(request.NewRequest(context.Background(), "/get"), nil)
+	assert.ErrorIs(t, err, ErrBlockedHost)
+}
+
+func TestClient_UTLSFingerprintProxy(t *testing.T) {
+	testServer := httptest.NewTLSServer(httpbulb.NewRouter())
+	defer testServer.Close()
+
+	// the handshake through a proxy is made by the transport, so the fingerprint can't be applied
+	for _, opt := range []ClientOption{
+		WithProxy("http://127.0.0.1:3128"),
+		WithProxyRotation([]string{"http://127.0.0.1:3128"}, RoundRobin),
+	} {
+		client := New(
+			WithBaseUrl(testServer.URL),
+			WithHttpClient(testServer.Client()),
+			opt,
+			WithUTLSFingerprint(utls.HelloChrome_Auto),
+		)
+		_, err := client.Fetch(request.NewRequest(context.Background(), "/get"), nil)
+		assert.ErrorIs(t, err, ErrFingerprintProxy)
+	}
+}